@@ -1,10 +1,14 @@
 package config
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"gopkg.in/yaml.v3"
@@ -23,7 +27,9 @@ type Config struct {
 	Meta               []MetaConfig              `json:"Meta" yaml:"meta"`
 	PhotoStorage       PhotoStorageConfig        `json:"PhotoStorage" yaml:"photoStorage"`
 	StaticStorage      StaticStorageConfig       `json:"StaticStorage" yaml:"staticStorage" validate:"required"`
+	ShareCard          ShareCardConfig           `json:"ShareCard" yaml:"shareCard" validate:"required"`
 	AllowOrigins       []string                  `json:"AllowOrigins" yaml:"allowOrigins"`
+	Webmention         WebmentionConfig          `json:"Webmention" yaml:"webmention"`
 }
 
 type EndpointConfig struct {
@@ -201,15 +207,21 @@ type FactGiverConfig struct {
 }
 
 type AvailableLanguageConfig struct {
-	Name    string `json:"Name" yaml:"name" validate:"required"`
-	Alt     string `json:"Alt" yaml:"alt"`
-	Flag    string `json:"Flag" yaml:"flag" validate:"url"`
-	LocFile string `json:"LocFile" yaml:"locFile" validate:"required,filepath"`
+	Name      string   `json:"Name" yaml:"name" validate:"required"`
+	Alt       string   `json:"Alt" yaml:"alt"`
+	Flag      string   `json:"Flag" yaml:"flag" validate:"url"`
+	LocFile   string   `json:"LocFile" yaml:"locFile" validate:"required,filepath"`
+	Reactions []string `json:"Reactions" yaml:"reactions" validate:"dive,required,max=16"`
 }
 
+// AuthConfig.PreviousSalts and MailConfig.PreviousSalts list the salts used
+// before the current one. Data hashed with them stays reachable and is moved
+// to the current salt when its owner returns
 type AuthConfig struct {
-	Salt string   `json:"Salt" yaml:"salt" validate:"required"`
-	Db   DbConfig `json:"Db" yaml:"db" validate:"required"`
+	Salt           string   `json:"Salt" yaml:"salt" validate:"required"`
+	PreviousSalts  []string `json:"PreviousSalts" yaml:"previousSalts"`
+	IdentitySecret string   `json:"IdentitySecret" yaml:"identitySecret" validate:"required"`
+	Db             DbConfig `json:"Db" yaml:"db" validate:"required"`
 }
 
 type DbConfig struct {
@@ -221,15 +233,124 @@ type Sqlite3Config struct {
 	DSN string `json:"DSN" yaml:"dsn" validate:"required"`
 }
 
+// MailConfig.UnsubscribeSecret signs the unsubscribe links in letters,
+// changing it breaks the links in every letter sent before
 type MailConfig struct {
-	ClientHost  string        `json:"ClientHost" yaml:"clientHost" validate:"required"`
-	MailHost    string        `json:"MailHost" yaml:"mailHost" validate:"required"`
-	PublicName  string        `json:"PublicName" yaml:"publicName" validate:"required"`
-	MailAddress string        `json:"MailAddress" yaml:"mailAddress" validate:"required"`
-	Username    string        `json:"Username" yaml:"username" validate:"required"`
-	Password    string        `json:"Password" yaml:"password" validate:"required"`
-	Salt        string        `json:"Salt" yaml:"salt" validate:"required"`
-	Trigger     TriggerConfig `json:"Trigger" yaml:"trigger" validate:"required"`
+	ClientHost        string              `json:"ClientHost" yaml:"clientHost" validate:"required"`
+	PublicName        string              `json:"PublicName" yaml:"publicName" validate:"required"`
+	MailAddress       string              `json:"MailAddress" yaml:"mailAddress" validate:"required"`
+	AuthorAddress     string              `json:"AuthorAddress" yaml:"authorAddress" validate:"required,email"`
+	Salt              string              `json:"Salt" yaml:"salt" validate:"required"`
+	PreviousSalts     []string            `json:"PreviousSalts" yaml:"previousSalts"`
+	UnsubscribeSecret string              `json:"UnsubscribeSecret" yaml:"unsubscribeSecret" validate:"required"`
+	SendPerMinute     int                 `json:"SendPerMinute" yaml:"sendPerMinute" validate:"required,min=1"`
+	Transport         MailTransportConfig `json:"Transport" yaml:"transport" validate:"required"`
+	Trigger           TriggerConfig       `json:"Trigger" yaml:"trigger" validate:"required"`
+}
+
+// MailTransportConfig.Type selects how messages leave the server: "smtp"
+// sends them to a mail server, "file" writes them to a directory as .eml
+// files, "log" prints them to the log and "sendmail" pipes them to a
+// sendmail-compatible binary. Only "smtp" needs credentials
+type MailTransportConfig struct {
+	Type   string `json:"Type" yaml:"type" validate:"required,oneof=smtp file log sendmail"`
+	Config any    `json:"Config" yaml:"config"`
+}
+
+func (mc *MailTransportConfig) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Type   string          `json:"Type"`
+		Config json.RawMessage `json:"Config"`
+	}
+
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	mc.Type = tmp.Type
+
+	switch tmp.Type {
+	case "smtp":
+		var smtpConfig SmtpConfig
+		if err := json.Unmarshal(tmp.Config, &smtpConfig); err != nil {
+			return fmt.Errorf("unmarshal SmtpConfig: %w", err)
+		}
+		mc.Config = &smtpConfig
+	case "file":
+		var mailFileConfig MailFileConfig
+		if err := json.Unmarshal(tmp.Config, &mailFileConfig); err != nil {
+			return fmt.Errorf("unmarshal MailFileConfig: %w", err)
+		}
+		mc.Config = &mailFileConfig
+	case "log":
+	case "sendmail":
+		var sendmailConfig SendmailConfig
+		if len(tmp.Config) > 0 {
+			if err := json.Unmarshal(tmp.Config, &sendmailConfig); err != nil {
+				return fmt.Errorf("unmarshal SendmailConfig: %w", err)
+			}
+		}
+		mc.Config = &sendmailConfig
+	default:
+		return fmt.Errorf("unsupported mail transport type: %s", tmp.Type)
+	}
+
+	return nil
+}
+
+func (mc *MailTransportConfig) UnmarshalYAML(value *yaml.Node) error {
+	var tmp struct {
+		Type   string    `yaml:"type"`
+		Config yaml.Node `yaml:"config"`
+	}
+
+	if err := value.Decode(&tmp); err != nil {
+		return err
+	}
+
+	mc.Type = tmp.Type
+
+	switch tmp.Type {
+	case "smtp":
+		var smtpConfig SmtpConfig
+		if err := tmp.Config.Decode(&smtpConfig); err != nil {
+			return fmt.Errorf("unmarshal SmtpConfig: %w", err)
+		}
+		mc.Config = &smtpConfig
+	case "file":
+		var mailFileConfig MailFileConfig
+		if err := tmp.Config.Decode(&mailFileConfig); err != nil {
+			return fmt.Errorf("unmarshal MailFileConfig: %w", err)
+		}
+		mc.Config = &mailFileConfig
+	case "log":
+	case "sendmail":
+		var sendmailConfig SendmailConfig
+		if !tmp.Config.IsZero() {
+			if err := tmp.Config.Decode(&sendmailConfig); err != nil {
+				return fmt.Errorf("unmarshal SendmailConfig: %w", err)
+			}
+		}
+		mc.Config = &sendmailConfig
+	default:
+		return fmt.Errorf("unsupported mail transport type: %s", tmp.Type)
+	}
+
+	return nil
+}
+
+type SmtpConfig struct {
+	Host     string `json:"Host" yaml:"host" validate:"required"`
+	Username string `json:"Username" yaml:"username" validate:"required"`
+	Password string `json:"Password" yaml:"password" validate:"required"`
+}
+
+type MailFileConfig struct {
+	Dir string `json:"Dir" yaml:"dir" validate:"required,dirpath"`
+}
+
+type SendmailConfig struct {
+	Path string `json:"Path" yaml:"path"`
 }
 
 type TriggerConfig struct {
@@ -242,28 +363,104 @@ type MetaConfig struct {
 }
 
 type PhotoStorageConfig struct {
-	Full           PhotoTypeConfig    `json:"Full" yaml:"full" validate:"required"`
-	Webp           PhotoTypeConfig    `json:"Webp" yaml:"webp"`
-	Thumbnail1600p PhotoTypeConfig    `json:"Thumbnail1600p" yaml:"thumbnail1600p"`
-	Thumbnail1200p PhotoTypeConfig    `json:"Thumbnail1200p" yaml:"thumbnail1200p"`
-	Thumbnail800p  PhotoTypeConfig    `json:"Thumbnail800p" yaml:"thumbnail800p"`
-	Thumbnail560p  PhotoTypeConfig    `json:"Thumbnail560p" yaml:"thumbnail560p"`
-	Thumbnail320p  PhotoTypeConfig    `json:"Thumbnail320p" yaml:"thumbnail320p"`
-	HomePageGifs   HomePageGifsConfig `json:"HomePageGifs" yaml:"homePageGifs"`
+	Full          PhotoTypeConfig      `json:"Full" yaml:"full" validate:"required"`
+	Webp          PhotoTypeConfig      `json:"Webp" yaml:"webp"`
+	DefaultFormat string               `json:"DefaultFormat" yaml:"defaultFormat" validate:"required"`
+	Variants      []PhotoVariantConfig `json:"Variants" yaml:"variants" validate:"required,min=1,dive"`
+	Sizes         string               `json:"Sizes" yaml:"sizes"`
+	Sizes2x       string               `json:"Sizes2x" yaml:"sizes2x"`
+	HomePageGifs  HomePageGifsConfig   `json:"HomePageGifs" yaml:"homePageGifs"`
 }
 
 type PhotoTypeConfig struct {
 	BaseUrl string `json:"BaseUrl" yaml:"baseUrl" validate:"url,required"`
 }
 
+// PhotoVariantConfig is a resized copy of every photo, BaseUrl takes the photo
+// path without an extension
+type PhotoVariantConfig struct {
+	Width   int    `json:"Width" yaml:"width" validate:"required,gt=0"`
+	Format  string `json:"Format" yaml:"format" validate:"required"`
+	BaseUrl string `json:"BaseUrl" yaml:"baseUrl" validate:"url,required"`
+}
+
+// VariantsOf returns variants of the format ordered by width
+func (c PhotoStorageConfig) VariantsOf(format string) []PhotoVariantConfig {
+	variants := make([]PhotoVariantConfig, 0, len(c.Variants))
+	for _, variant := range c.Variants {
+		if variant.Format == format {
+			variants = append(variants, variant)
+		}
+	}
+	slices.SortFunc(variants, func(a, b PhotoVariantConfig) int {
+		return cmp.Compare(a.Width, b.Width)
+	})
+	return variants
+}
+
+// AlternativeFormats returns the formats other than the default one in the
+// order they first appear in the config
+func (c PhotoStorageConfig) AlternativeFormats() []string {
+	formats := make([]string, 0)
+	for _, variant := range c.Variants {
+		if variant.Format != c.DefaultFormat && !slices.Contains(formats, variant.Format) {
+			formats = append(formats, variant.Format)
+		}
+	}
+	return formats
+}
+
+// VariantBaseUrl returns the url template of the narrowest default format
+// variant at least minWidth wide, or the widest one if none is wide enough
+func (c PhotoStorageConfig) VariantBaseUrl(minWidth int) string {
+	variants := c.VariantsOf(c.DefaultFormat)
+	if len(variants) == 0 {
+		return c.Full.BaseUrl
+	}
+	for _, variant := range variants {
+		if variant.Width >= minWidth {
+			return variant.BaseUrl
+		}
+	}
+	return variants[len(variants)-1].BaseUrl
+}
+
+// VariantUrl returns the url of the photo in the narrowest default format
+// variant at least minWidth wide
+func (c PhotoStorageConfig) VariantUrl(minWidth int, path string) string {
+	return fmt.Sprintf(c.VariantBaseUrl(minWidth), path)
+}
+
+// Srcset returns the srcset attribute value listing all variants of the format
+func (c PhotoStorageConfig) Srcset(format string, path string) string {
+	variants := c.VariantsOf(format)
+	candidates := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		candidates = append(candidates, fmt.Sprintf(variant.BaseUrl, path)+" "+strconv.Itoa(variant.Width)+"w")
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// FormatMimeType returns the mime type of an image format, e.g. "image/avif"
+func FormatMimeType(format string) string {
+	switch format {
+	case "jpg":
+		return "image/jpeg"
+	case "svg":
+		return "image/svg+xml"
+	}
+	return "image/" + format
+}
+
 type HomePageGifsConfig struct {
 	BaseUrl string   `json:"BaseUrl" yaml:"baseUrl" validate:"url,required"`
 	Indexes []string `json:"Indexes" yaml:"indexes"`
 }
 
 type StaticStorageConfig struct {
-	BaseUrl string           `json:"BaseUrl" yaml:"baseUrl" validate:"url,required"`
-	Map     MapStorageConfig `json:"Map" yaml:"map" validate:"required"`
+	BaseUrl string             `json:"BaseUrl" yaml:"baseUrl" validate:"url,required"`
+	Map     MapStorageConfig   `json:"Map" yaml:"map" validate:"required"`
+	Media   MediaStorageConfig `json:"Media" yaml:"media" validate:"required"`
 }
 
 type MapStorageConfig struct {
@@ -271,6 +468,26 @@ type MapStorageConfig struct {
 	PMTiles string `json:"PMTiles" yaml:"pmTiles" validate:"required"`
 }
 
+// MediaStorageConfig is where the video and audio files embedded in posts lie,
+// under the same namespaces as the photos
+type MediaStorageConfig struct {
+	BaseUrl string `json:"BaseUrl" yaml:"baseUrl" validate:"url,required"`
+}
+
+// ShareCardConfig configures the generated Open Graph images, CacheDir keeps
+// the rendered cards between restarts
+type ShareCardConfig struct {
+	CacheDir string `json:"CacheDir" yaml:"cacheDir" validate:"required"`
+	SiteName string `json:"SiteName" yaml:"siteName" validate:"required"`
+}
+
+// WebmentionConfig.AllowPrivateNetworks lets webmention sources and targets, as
+// well as ActivityPub inboxes, resolve to loopback and private addresses, which
+// is only meant for local runs
+type WebmentionConfig struct {
+	AllowPrivateNetworks bool `json:"AllowPrivateNetworks" yaml:"allowPrivateNetworks"`
+}
+
 func LoadConfig(path string, config *Config) error {
 	fileBytes, err := os.ReadFile(path)
 	if err != nil {
@@ -295,6 +512,9 @@ func InitConfig(path string) (*Config, error) {
 	if config.Endpoint.Type == "unix" && config.Endpoint.Config.(*UnixConfig).Chmod == "" {
 		config.Endpoint.Config.(*UnixConfig).Chmod = "0660"
 	}
+	if config.Mail.Transport.Type == "sendmail" && config.Mail.Transport.Config.(*SendmailConfig).Path == "" {
+		config.Mail.Transport.Config.(*SendmailConfig).Path = "/usr/sbin/sendmail"
+	}
 
 	validate := validator.New(validator.WithRequiredStructEnabled())
 	if err := validate.Struct(config); err != nil {
@@ -1,9 +1,11 @@
 module github.com/SayaAndy/saya-today-web
 
-go 1.26
+go 1.26.0
 
 require (
 	github.com/Backblaze/blazer v0.7.2
+	github.com/alecthomas/chroma/v2 v2.27.0
+	github.com/buckket/go-blurhash v1.1.0
 	github.com/dgraph-io/ristretto/v2 v2.4.0
 	github.com/go-co-op/gocron/v2 v2.21.2
 	github.com/go-playground/validator/v10 v10.30.2
@@ -14,6 +16,7 @@ require (
 	github.com/wneessen/go-mail v0.7.3
 	github.com/yuin/goldmark v1.8.2
 	golang.org/x/crypto v0.51.0
+	golang.org/x/image v0.46.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -23,6 +26,7 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/sso v1.30.17 // indirect
 	github.com/aws/aws-sdk-go-v2/service/ssooidc v1.35.21 // indirect
 	github.com/aws/aws-sdk-go-v2/service/sts v1.42.1 // indirect
+	github.com/dlclark/regexp2/v2 v2.2.1 // indirect
 	github.com/philhofer/fwd v1.2.0 // indirect
 	github.com/tinylib/msgp v1.6.4 // indirect
 )
@@ -58,6 +62,6 @@ require (
 	github.com/mattn/go-runewidth v0.0.23 // indirect
 	github.com/robfig/cron/v3 v3.0.1 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
-	golang.org/x/sys v0.44.0 // indirect
-	golang.org/x/text v0.37.0 // indirect
+	golang.org/x/sys v0.48.0 // indirect
+	golang.org/x/text v0.42.0 // indirect
 )
@@ -0,0 +1,540 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/config"
+	"github.com/SayaAndy/saya-today-web/internal/blog"
+	"github.com/SayaAndy/saya-today-web/internal/lru"
+)
+
+const (
+	ContentType = "application/activity+json; charset=utf-8"
+	publicTo    = "https://www.w3.org/ns/activitystreams#Public"
+
+	keySize         = 2048
+	actorsCacheSize = 1024
+	maxBodySize     = 1 << 20
+)
+
+var (
+	ErrNotFound    = errors.New("actor not found")
+	ErrBadActivity = errors.New("activity is malformed")
+
+	activityContext = []any{"https://www.w3.org/ns/activitystreams", "https://w3id.org/security/v1"}
+)
+
+// Federation publishes the blog of every language as an ActivityPub actor,
+// which fediverse users can follow to get new posts in their timelines
+type Federation struct {
+	db                *sql.DB
+	client            *http.Client
+	canonicalEndpoint string
+	host              string
+	photoStorage      config.PhotoStorageConfig
+	keys              map[string]*rsa.PrivateKey
+	publicKeys        map[string]string
+	actors            *lru.Cache[string, *remoteActor]
+}
+
+type remoteActor struct {
+	Id        string `json:"id"`
+	Inbox     string `json:"inbox"`
+	Endpoints struct {
+		SharedInbox string `json:"sharedInbox"`
+	} `json:"endpoints"`
+	PublicKey struct {
+		Id           string `json:"id"`
+		Owner        string `json:"owner"`
+		PublicKeyPem string `json:"publicKeyPem"`
+	} `json:"publicKey"`
+}
+
+type activity struct {
+	Id     string          `json:"id"`
+	Type   string          `json:"type"`
+	Actor  string          `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+func NewFederation(db *sql.DB, client *http.Client, canonicalEndpoint string, langs []string, photoStorage config.PhotoStorageConfig) (*Federation, error) {
+	canonicalUrl, err := url.Parse(canonicalEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("fail to parse canonical endpoint: %w", err)
+	}
+
+	f := &Federation{
+		db:                db,
+		client:            client,
+		canonicalEndpoint: canonicalEndpoint,
+		host:              canonicalUrl.Host,
+		photoStorage:      photoStorage,
+		keys:              make(map[string]*rsa.PrivateKey, len(langs)),
+		publicKeys:        make(map[string]string, len(langs)),
+		actors:            lru.New[string, *remoteActor](actorsCacheSize),
+	}
+
+	for _, lang := range langs {
+		if f.keys[lang], err = loadOrCreateKey(db, lang); err != nil {
+			return nil, err
+		}
+		if f.publicKeys[lang], err = encodePublicKey(&f.keys[lang].PublicKey); err != nil {
+			return nil, fmt.Errorf("fail to encode public key of '%s' actor: %w", lang, err)
+		}
+	}
+	return f, nil
+}
+
+func loadOrCreateKey(db *sql.DB, lang string) (*rsa.PrivateKey, error) {
+	var encoded string
+	err := db.QueryRow(`SELECT private_key FROM activitypub_key_table WHERE lang=?;`, lang).Scan(&encoded)
+	if err == nil {
+		block, _ := pem.Decode([]byte(encoded))
+		if block == nil {
+			return nil, fmt.Errorf("private key of '%s' actor is not PEM encoded", lang)
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("fail to parse private key of '%s' actor: %w", lang, err)
+		}
+		key, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key of '%s' actor is not an RSA key", lang)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("fail to query private key of '%s' actor: %w", lang, err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("fail to generate private key of '%s' actor: %w", lang, err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("fail to encode private key of '%s' actor: %w", lang, err)
+	}
+	if _, err = db.Exec(`INSERT INTO activitypub_key_table(lang, private_key) VALUES(?, ?);`,
+		lang, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))); err != nil {
+		return nil, fmt.Errorf("fail to save private key of '%s' actor: %w", lang, err)
+	}
+	slog.Info("generated a key for activitypub actor", slog.String("lang", lang))
+	return key, nil
+}
+
+func (f *Federation) ActorId(lang string) string {
+	return fmt.Sprintf("%s/%s/actor", f.canonicalEndpoint, lang)
+}
+
+func (f *Federation) keyId(lang string) string {
+	return f.ActorId(lang) + "#main-key"
+}
+
+func (f *Federation) collectionId(lang string, name string) string {
+	return fmt.Sprintf("%s/%s/%s", f.canonicalEndpoint, lang, name)
+}
+
+// WebFinger resolves an "acct:lang@host" resource or an actor id into the
+// JRD document pointing to the actor
+func (f *Federation) WebFinger(resource string) (map[string]any, error) {
+	var lang string
+	if account, ok := strings.CutPrefix(resource, "acct:"); ok {
+		username, host, found := strings.Cut(account, "@")
+		if !found || !strings.EqualFold(host, f.host) {
+			return nil, ErrNotFound
+		}
+		lang = username
+	} else {
+		for candidate := range f.keys {
+			if resource == f.ActorId(candidate) {
+				lang = candidate
+			}
+		}
+	}
+	if _, ok := f.keys[lang]; !ok {
+		return nil, ErrNotFound
+	}
+
+	return map[string]any{
+		"subject": fmt.Sprintf("acct:%s@%s", lang, f.host),
+		"aliases": []string{f.ActorId(lang)},
+		"links": []map[string]any{
+			{"rel": "self", "type": "application/activity+json", "href": f.ActorId(lang)},
+			{"rel": "http://webfinger.net/rel/profile-page", "type": "text/html", "href": fmt.Sprintf("%s/%s/blog", f.canonicalEndpoint, lang)},
+		},
+	}, nil
+}
+
+// Actor returns the actor document of the language
+func (f *Federation) Actor(lang string, name string, summary string) (map[string]any, error) {
+	if _, ok := f.keys[lang]; !ok {
+		return nil, ErrNotFound
+	}
+
+	return map[string]any{
+		"@context":                  activityContext,
+		"id":                        f.ActorId(lang),
+		"type":                      "Service",
+		"preferredUsername":         lang,
+		"name":                      name,
+		"summary":                   summary,
+		"url":                       fmt.Sprintf("%s/%s/blog", f.canonicalEndpoint, lang),
+		"inbox":                     f.collectionId(lang, "inbox"),
+		"outbox":                    f.collectionId(lang, "outbox"),
+		"followers":                 f.collectionId(lang, "followers"),
+		"manuallyApprovesFollowers": false,
+		"discoverable":              true,
+		"publicKey": map[string]any{
+			"id":           f.keyId(lang),
+			"owner":        f.ActorId(lang),
+			"publicKeyPem": f.publicKeys[lang],
+		},
+	}, nil
+}
+
+// Outbox returns the collection of Create activities of the posts, newest first
+func (f *Federation) Outbox(lang string, posts []*blog.Page) map[string]any {
+	posts = slices.Clone(posts)
+	slices.SortFunc(posts, func(a, b *blog.Page) int {
+		return b.Metadata.PublishedTime.Compare(a.Metadata.PublishedTime)
+	})
+
+	items := make([]map[string]any, 0, len(posts))
+	for _, post := range posts {
+		create := f.create(lang, post)
+		delete(create, "@context")
+		items = append(items, create)
+	}
+
+	return map[string]any{
+		"@context":     activityContext,
+		"id":           f.collectionId(lang, "outbox"),
+		"type":         "OrderedCollection",
+		"totalItems":   len(items),
+		"orderedItems": items,
+	}
+}
+
+// Followers returns the followers collection of the language, it only tells
+// how many followers there are
+func (f *Federation) Followers(lang string) (map[string]any, error) {
+	var count int
+	if err := f.db.QueryRow(`SELECT COUNT(*) FROM activitypub_follower_table WHERE lang=?;`, lang).Scan(&count); err != nil {
+		return nil, fmt.Errorf("fail to count followers: %w", err)
+	}
+
+	return map[string]any{
+		"@context":   activityContext,
+		"id":         f.collectionId(lang, "followers"),
+		"type":       "OrderedCollection",
+		"totalItems": count,
+	}, nil
+}
+
+func (f *Federation) article(lang string, post *blog.Page) map[string]any {
+	link := fmt.Sprintf("%s/%s/blog/%s", f.canonicalEndpoint, lang, post.FileName)
+
+	content := fmt.Sprintf(`<p><a href="%s">%s</a></p>`, html.EscapeString(link), html.EscapeString(post.Metadata.Title))
+	if post.Metadata.ShortDescription != "" {
+		content = fmt.Sprintf("<p>%s</p>", html.EscapeString(post.Metadata.ShortDescription)) + content
+	}
+
+	tags := make([]map[string]any, 0, len(post.Metadata.Tags))
+	for _, tag := range post.Metadata.Tags {
+		tags = append(tags, map[string]any{"type": "Hashtag", "name": "#" + strings.Join(strings.Fields(tag), "")})
+	}
+
+	article := map[string]any{
+		"id":           link,
+		"type":         "Article",
+		"attributedTo": f.ActorId(lang),
+		"name":         post.Metadata.Title,
+		"content":      content,
+		"url":          link,
+		"published":    post.Metadata.PublishedTime.UTC().Format(time.RFC3339),
+		"to":           []string{publicTo},
+		"cc":           []string{f.collectionId(lang, "followers")},
+		"tag":          tags,
+	}
+	if post.Metadata.Thumbnail != "" {
+		article["attachment"] = []map[string]any{{
+			"type": "Image",
+			"url":  f.photoStorage.VariantUrl(800, post.Metadata.Thumbnail),
+			"name": post.Metadata.Title,
+		}}
+	}
+	return article
+}
+
+func (f *Federation) create(lang string, post *blog.Page) map[string]any {
+	article := f.article(lang, post)
+	return map[string]any{
+		"@context":  activityContext,
+		"id":        article["id"].(string) + "#create",
+		"type":      "Create",
+		"actor":     f.ActorId(lang),
+		"published": article["published"],
+		"to":        article["to"],
+		"cc":        article["cc"],
+		"object":    article,
+	}
+}
+
+// HandleInbox verifies the signature of an activity sent to the inbox of the
+// language and applies it, only follows and their undoing matter to the blog
+func (f *Federation) HandleInbox(lang string, method string, requestTarget string, header func(name string) string, body []byte) error {
+	if _, ok := f.keys[lang]; !ok {
+		return ErrNotFound
+	}
+
+	sig, err := parseSignature(header("signature"))
+	if err != nil {
+		return err
+	}
+	sender, err := f.verify(lang, sig, method, requestTarget, header, body)
+	if err != nil {
+		return err
+	}
+
+	var received activity
+	if err = json.Unmarshal(body, &received); err != nil {
+		return fmt.Errorf("%w: %w", ErrBadActivity, err)
+	}
+	if received.Actor != sender.Id {
+		return fmt.Errorf("activity actor '%s' is not the signer '%s': %w", received.Actor, sender.Id, ErrInvalidSignature)
+	}
+
+	switch received.Type {
+	case "Follow":
+		if objectId(received.Object) != f.ActorId(lang) {
+			return fmt.Errorf("follow of another actor: %w", ErrBadActivity)
+		}
+		if err = f.saveFollower(lang, sender); err != nil {
+			return err
+		}
+		slog.Info("new activitypub follower", slog.String("lang", lang), slog.String("follower", sender.Id))
+
+		// the body and the language may point into the request buffer, which is
+		// reused once the inbox responds
+		lang, body := strings.Clone(lang), bytes.Clone(body)
+		go func() {
+			accept := map[string]any{
+				"@context": activityContext,
+				"id":       fmt.Sprintf("%s#accepts/%s", f.ActorId(lang), randomId()),
+				"type":     "Accept",
+				"actor":    f.ActorId(lang),
+				"object":   json.RawMessage(body),
+			}
+			if err := f.post(lang, sender.Inbox, accept); err != nil {
+				slog.Warn("failed to accept activitypub follow", slog.String("follower", sender.Id), slog.String("error", err.Error()))
+			}
+		}()
+
+	case "Undo":
+		var undone activity
+		if err = json.Unmarshal(received.Object, &undone); err != nil {
+			return fmt.Errorf("%w: %w", ErrBadActivity, err)
+		}
+		if undone.Type == "Follow" {
+			if _, err = f.db.Exec(`DELETE FROM activitypub_follower_table WHERE lang=? AND follower_id=?;`, lang, sender.Id); err != nil {
+				return fmt.Errorf("fail to delete follower: %w", err)
+			}
+			slog.Info("activitypub follower left", slog.String("lang", lang), slog.String("follower", sender.Id))
+		}
+
+	case "Delete":
+		if objectId(received.Object) == sender.Id {
+			if _, err = f.db.Exec(`DELETE FROM activitypub_follower_table WHERE follower_id=?;`, sender.Id); err != nil {
+				return fmt.Errorf("fail to delete follower: %w", err)
+			}
+		}
+
+	default:
+		slog.Debug("ignored activitypub activity", slog.String("type", received.Type), slog.String("actor", received.Actor))
+	}
+	return nil
+}
+
+// verify checks the request is signed by the key it names and returns the
+// owner of the key. A cached actor is refetched once in case its key rotated
+func (f *Federation) verify(lang string, sig *signature, method string, requestTarget string, header func(name string) string, body []byte) (*remoteActor, error) {
+	actorUrl, _, _ := strings.Cut(sig.keyId, "#")
+
+	if sender, ok := f.actors.Get(actorUrl); ok {
+		if err := verifyBy(sender, sig, method, requestTarget, header, body); err == nil {
+			return sender, nil
+		}
+	}
+
+	sender, err := f.fetchActor(lang, actorUrl)
+	if err != nil {
+		return nil, fmt.Errorf("fail to fetch key '%s': %w: %w", sig.keyId, ErrInvalidSignature, err)
+	}
+	f.actors.Add(strings.Clone(actorUrl), sender)
+
+	if err = verifyBy(sender, sig, method, requestTarget, header, body); err != nil {
+		return nil, err
+	}
+	return sender, nil
+}
+
+func verifyBy(sender *remoteActor, sig *signature, method string, requestTarget string, header func(name string) string, body []byte) error {
+	if sender.PublicKey.Id != sig.keyId {
+		return fmt.Errorf("key '%s' is not the key of '%s': %w", sig.keyId, sender.Id, ErrInvalidSignature)
+	}
+	key, err := decodePublicKey(sender.PublicKey.PublicKeyPem)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	return verifyRequest(sig, key, method, requestTarget, header, body)
+}
+
+func (f *Federation) fetchActor(lang string, actorUrl string) (*remoteActor, error) {
+	request, err := http.NewRequest("GET", actorUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", `application/activity+json, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`)
+	if err = signRequest(request, nil, f.keyId(lang), f.keys[lang]); err != nil {
+		return nil, err
+	}
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("actor responded with status %d", response.StatusCode)
+	}
+
+	var actor remoteActor
+	if err = json.NewDecoder(io.LimitReader(response.Body, maxBodySize)).Decode(&actor); err != nil {
+		return nil, fmt.Errorf("fail to decode actor: %w", err)
+	}
+
+	fetchedId, err := url.Parse(actor.Id)
+	if err != nil || fetchedId.Host != request.URL.Host {
+		return nil, fmt.Errorf("actor id '%s' does not belong to '%s'", actor.Id, request.URL.Host)
+	}
+	if actor.Inbox == "" || actor.PublicKey.Owner != actor.Id {
+		return nil, fmt.Errorf("actor '%s' lacks an inbox or its own key", actor.Id)
+	}
+	return &actor, nil
+}
+
+func (f *Federation) saveFollower(lang string, follower *remoteActor) error {
+	if _, err := f.db.Exec(`INSERT INTO activitypub_follower_table(lang, follower_id, inbox, shared_inbox, followed_at) VALUES(?, ?, ?, ?, ?)
+  ON CONFLICT(lang, follower_id) DO UPDATE SET inbox=excluded.inbox, shared_inbox=excluded.shared_inbox;`,
+		lang, follower.Id, follower.Inbox, follower.Endpoints.SharedInbox, time.Now().Unix()); err != nil {
+		return fmt.Errorf("fail to save follower: %w", err)
+	}
+	return nil
+}
+
+// Deliver sends the new post to the inboxes of the followers of its language,
+// a shared inbox receives it once for all the followers behind it
+func (f *Federation) Deliver(post *blog.Page) error {
+	if _, ok := f.keys[post.Lang]; !ok {
+		return ErrNotFound
+	}
+
+	rows, err := f.db.Query(`SELECT inbox, shared_inbox FROM activitypub_follower_table WHERE lang=?;`, post.Lang)
+	if err != nil {
+		return fmt.Errorf("fail to query followers: %w", err)
+	}
+	inboxes := make([]string, 0)
+	for rows.Next() {
+		var inbox, sharedInbox string
+		if err = rows.Scan(&inbox, &sharedInbox); err != nil {
+			rows.Close()
+			return fmt.Errorf("fail scanning followers: %w", err)
+		}
+		if sharedInbox != "" {
+			inbox = sharedInbox
+		}
+		if !slices.Contains(inboxes, inbox) {
+			inboxes = append(inboxes, inbox)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("fail reading followers: %w", err)
+	}
+
+	create := f.create(post.Lang, post)
+	errs := make([]error, 0)
+	for _, inbox := range inboxes {
+		if err = f.post(post.Lang, inbox, create); err != nil {
+			errs = append(errs, fmt.Errorf("fail to deliver to '%s': %w", inbox, err))
+		}
+	}
+	slog.Info("delivered new post to activitypub followers", slog.String("lang", post.Lang), slog.String("codename", post.FileName),
+		slog.Int("inboxes", len(inboxes)), slog.Int("failed", len(errs)))
+	return errors.Join(errs...)
+}
+
+func (f *Federation) post(lang string, inbox string, activity map[string]any) error {
+	body, err := json.Marshal(activity)
+	if err != nil {
+		return fmt.Errorf("fail to encode activity: %w", err)
+	}
+
+	request, err := http.NewRequest("POST", inbox, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", ContentType)
+	if err = signRequest(request, body, f.keyId(lang), f.keys[lang]); err != nil {
+		return err
+	}
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, maxBodySize))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("inbox responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// objectId returns the id of an object given either inline or by its id
+func objectId(object json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(object, &id); err == nil {
+		return id
+	}
+	var inline struct {
+		Id string `json:"id"`
+	}
+	json.Unmarshal(object, &inline)
+	return inline.Id
+}
+
+func randomId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,182 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxClockSkew is how far the Date of a signed request may be from now
+const maxClockSkew = 12 * time.Hour
+
+var (
+	ErrUnsigned         = errors.New("request is not signed")
+	ErrInvalidSignature = errors.New("request signature is invalid")
+)
+
+var signatureParamRegexp = regexp.MustCompile(`([a-zA-Z]+)="([^"]*)"`)
+
+// signature is a parsed Signature header of the draft-cavage HTTP signatures
+type signature struct {
+	keyId     string
+	algorithm string
+	headers   []string
+	signature []byte
+}
+
+func parseSignature(header string) (*signature, error) {
+	if header == "" {
+		return nil, ErrUnsigned
+	}
+
+	sig := &signature{headers: []string{"date"}}
+	for _, param := range signatureParamRegexp.FindAllStringSubmatch(header, -1) {
+		switch param[1] {
+		case "keyId":
+			sig.keyId = param[2]
+		case "algorithm":
+			sig.algorithm = param[2]
+		case "headers":
+			sig.headers = strings.Fields(strings.ToLower(param[2]))
+		case "signature":
+			decoded, err := base64.StdEncoding.DecodeString(param[2])
+			if err != nil {
+				return nil, fmt.Errorf("fail to decode signature: %w", err)
+			}
+			sig.signature = decoded
+		}
+	}
+
+	if sig.keyId == "" || len(sig.signature) == 0 {
+		return nil, fmt.Errorf("signature lacks keyId or signature: %w", ErrInvalidSignature)
+	}
+	if sig.algorithm != "" && sig.algorithm != "rsa-sha256" && sig.algorithm != "hs2019" {
+		return nil, fmt.Errorf("unsupported signature algorithm '%s': %w", sig.algorithm, ErrInvalidSignature)
+	}
+	return sig, nil
+}
+
+// signingString builds the string a request is signed over from the listed
+// headers, header returns the value of a header by its lowercase name
+func signingString(headers []string, method string, requestTarget string, header func(name string) string) string {
+	lines := make([]string, 0, len(headers))
+	for _, name := range headers {
+		if name == "(request-target)" {
+			lines = append(lines, fmt.Sprintf("(request-target): %s %s", strings.ToLower(method), requestTarget))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", name, header(name)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// verifyRequest checks the signature of an incoming request covers the
+// request target, its date and the digest of the body, and is made by the key
+func verifyRequest(sig *signature, key *rsa.PublicKey, method string, requestTarget string, header func(name string) string, body []byte) error {
+	for _, required := range []string{"(request-target)", "host", "date"} {
+		if !slices.Contains(sig.headers, required) {
+			return fmt.Errorf("signature does not cover '%s': %w", required, ErrInvalidSignature)
+		}
+	}
+
+	date, err := http.ParseTime(header("date"))
+	if err != nil {
+		return fmt.Errorf("fail to parse date: %w", ErrInvalidSignature)
+	}
+	if skew := time.Since(date); skew > maxClockSkew || skew < -maxClockSkew {
+		return fmt.Errorf("request date is too far from now: %w", ErrInvalidSignature)
+	}
+
+	if method != http.MethodGet {
+		if !slices.Contains(sig.headers, "digest") {
+			return fmt.Errorf("signature does not cover 'digest': %w", ErrInvalidSignature)
+		}
+		if header("digest") != digest(body) {
+			return fmt.Errorf("digest does not match the body: %w", ErrInvalidSignature)
+		}
+	}
+
+	hash := sha256.Sum256([]byte(signingString(sig.headers, method, requestTarget, header)))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig.signature); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// signRequest signs an outgoing request with the key of an actor, body must be
+// the request body or nil for GET requests
+func signRequest(request *http.Request, body []byte, keyId string, key *rsa.PrivateKey) error {
+	request.Header.Set("Host", request.URL.Host)
+	request.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		request.Header.Set("Digest", digest(body))
+		headers = append(headers, "digest")
+	}
+
+	requestTarget := request.URL.RequestURI()
+	hash := sha256.Sum256([]byte(signingString(headers, request.Method, requestTarget, func(name string) string {
+		if name == "host" {
+			return request.URL.Host
+		}
+		return request.Header.Get(name)
+	})))
+	signed, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return fmt.Errorf("fail to sign request: %w", err)
+	}
+
+	request.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyId, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(signed)))
+	return nil
+}
+
+func digest(body []byte) string {
+	hash := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func encodePublicKey(key *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+func decodePublicKey(encoded string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fail to parse public key: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not an RSA key")
+	}
+	return key, nil
+}
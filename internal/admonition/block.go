@@ -0,0 +1,38 @@
+package admonition
+
+import (
+	"github.com/yuin/goldmark/ast"
+)
+
+// AdmonitionBlock represents a callout block in the AST
+type AdmonitionBlock struct {
+	ast.BaseBlock
+	AdmonitionKind string
+	Title          []byte
+	Lang           string
+}
+
+const (
+	Note      = "Note"
+	Tip       = "Tip"
+	Warning   = "Warning"
+	Spoiler   = "Spoiler"
+	TravelTip = "TravelTip"
+)
+
+var Kinds = []string{Note, Tip, Warning, Spoiler, TravelTip}
+
+var KindAdmonitionBlock = ast.NewNodeKind("AdmonitionBlock")
+
+// Dump implements ast.Node.Dump
+func (n *AdmonitionBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"AdmonitionKind": n.AdmonitionKind,
+		"Title":          string(n.Title),
+	}, nil)
+}
+
+// Kind implements ast.Node.Kind
+func (n *AdmonitionBlock) Kind() ast.NodeKind {
+	return KindAdmonitionBlock
+}
@@ -0,0 +1,28 @@
+package admonition
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// Extension that combines parser and renderer
+type AdmonitionExtension struct{}
+
+func NewAdmonitionExtension() goldmark.Extender {
+	return &AdmonitionExtension{}
+}
+
+func (e *AdmonitionExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(
+			util.Prioritized(NewAdmonitionParser(), 500),
+		),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(
+			util.Prioritized(NewAdmonitionHTMLRenderer(), 500),
+		),
+	)
+}
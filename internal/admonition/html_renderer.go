@@ -0,0 +1,79 @@
+package admonition
+
+import (
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+var icons = map[string]string{
+	Note:      "ℹ️",
+	Tip:       "💡",
+	Warning:   "⚠️",
+	Spoiler:   "🙈",
+	TravelTip: "🧭",
+}
+
+type AdmonitionHTMLRenderer struct {
+	html.Config
+}
+
+func NewAdmonitionHTMLRenderer(opts ...html.Option) renderer.NodeRenderer {
+	r := &AdmonitionHTMLRenderer{
+		Config: html.NewConfig(),
+	}
+	for _, opt := range opts {
+		opt.SetHTMLOption(&r.Config)
+	}
+	return r
+}
+
+func (r *AdmonitionHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindAdmonitionBlock, r.renderAdmonition)
+}
+
+func (r *AdmonitionHTMLRenderer) renderAdmonition(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	admonition := n.(*AdmonitionBlock)
+
+	tag := "aside"
+	if admonition.AdmonitionKind == Spoiler {
+		tag = "details"
+	}
+
+	if !entering {
+		w.WriteString("</" + tag + ">\n")
+		return ast.WalkContinue, nil
+	}
+
+	title := admonition.Title
+	if len(title) == 0 {
+		kindTitle, ok := l10n.T.GetPath(admonition.Lang, "Admonition", admonition.AdmonitionKind).(string)
+		if !ok {
+			kindTitle = admonition.AdmonitionKind
+		}
+		title = []byte(kindTitle)
+	}
+
+	w.WriteString("<" + tag + ` data-admonition="` + admonition.AdmonitionKind + `"`)
+	if admonition.Attributes() != nil {
+		html.RenderAttributes(w, admonition, html.GlobalAttributeFilter)
+	}
+	w.WriteString(">\n")
+
+	if tag == "details" {
+		w.WriteString(`<summary class="font-m-plus font-bold cursor-pointer select-none mb-1">`)
+	} else {
+		w.WriteString(`<p class="font-m-plus font-bold mb-1">`)
+	}
+	w.WriteString(`<span class="mr-1" aria-hidden="true">` + icons[admonition.AdmonitionKind] + `</span>`)
+	w.Write(util.EscapeHTML(title))
+	if tag == "details" {
+		w.WriteString("</summary>\n")
+	} else {
+		w.WriteString("</p>\n")
+	}
+
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,71 @@
+package admonition
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/SayaAndy/saya-today-web/internal/mdcontext"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+type AdmonitionParser struct {
+	openRe *regexp.Regexp
+}
+
+func NewAdmonitionParser() parser.BlockParser {
+	return &AdmonitionParser{
+		openRe: regexp.MustCompile(`^\{(` + strings.Join(Kinds, "|") + `)(?::([^}]*))?\}$`),
+	}
+}
+
+func (p *AdmonitionParser) Trigger() []byte {
+	return []byte{'{'}
+}
+
+func (p *AdmonitionParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+
+	parts := p.openRe.FindSubmatch(bytes.TrimSpace(line))
+	if parts == nil {
+		return nil, parser.NoChildren
+	}
+	reader.AdvanceLine()
+
+	return &AdmonitionBlock{
+		AdmonitionKind: string(parts[1]),
+		Title:          bytes.TrimSpace(parts[2]),
+		Lang:           mdcontext.Lang(pc),
+	}, parser.HasChildren
+}
+
+func (p *AdmonitionParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, segment := reader.PeekLine()
+
+	admonition := node.(*AdmonitionBlock)
+	if bytes.Equal(bytes.TrimSpace(line), []byte("{/"+admonition.AdmonitionKind+"}")) {
+		// leave the line break unread, so the rest of the line is seen as blank
+		// and does not continue a paragraph lazily
+		length := segment.Len()
+		if length > 0 && line[length-1] == '\n' {
+			length--
+		}
+		reader.Advance(length)
+		return parser.Close
+	}
+
+	return parser.Continue | parser.HasChildren
+}
+
+func (p *AdmonitionParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+}
+
+func (p *AdmonitionParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *AdmonitionParser) CanAcceptIndentedLine() bool {
+	return false
+}
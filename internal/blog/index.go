@@ -14,17 +14,18 @@ const MedleysIndexFileName = "medleys.json"
 const IndexSchemaVersion = 2
 
 type IndexEntry struct {
-	Link             string    `json:"link"`
-	ModifiedTime     time.Time `json:"modifiedTime"`
-	Title            string    `json:"title"`
-	ShortDescription string    `json:"shortDescription"`
-	ActionDate       string    `json:"actionDate"`
-	PublishedTime    time.Time `json:"publishedTime"`
-	Thumbnail        string    `json:"thumbnail"`
-	Tags             []string  `json:"tags"`
-	Geolocation      string    `json:"geolocation"`
-	Medley           string    `json:"medley,omitempty"`
-	MedleyPart       int       `json:"medleyPart,omitempty"`
+	Link             string            `json:"link"`
+	ModifiedTime     time.Time         `json:"modifiedTime"`
+	Title            string            `json:"title"`
+	ShortDescription string            `json:"shortDescription"`
+	ActionDate       string            `json:"actionDate"`
+	PublishedTime    time.Time         `json:"publishedTime"`
+	Thumbnail        string            `json:"thumbnail"`
+	Tags             []string          `json:"tags"`
+	Geolocation      string            `json:"geolocation"`
+	Medley           string            `json:"medley,omitempty"`
+	MedleyPart       int               `json:"medleyPart,omitempty"`
+	ContentSettings  map[string]string `json:"contentSettings,omitempty"`
 }
 
 func (e IndexEntry) Metadata() *frontmatter.Metadata {
@@ -38,6 +39,7 @@ func (e IndexEntry) Metadata() *frontmatter.Metadata {
 		Geolocation:      e.Geolocation,
 		Medley:           e.Medley,
 		MedleyPart:       e.MedleyPart,
+		ContentSettings:  e.ContentSettings,
 	}
 }
 
@@ -131,6 +131,7 @@ func (c *S3Client) Scan(prefix string) ([]*Page, error) {
 						Geolocation:      e.Geolocation,
 						Medley:           e.Medley,
 						MedleyPart:       e.MedleyPart,
+						ContentSettings:  e.ContentSettings,
 					},
 				})
 			}
@@ -163,6 +164,7 @@ func (c *S3Client) Scan(prefix string) ([]*Page, error) {
 						Geolocation:      e.Geolocation,
 						Medley:           e.Medley,
 						MedleyPart:       e.MedleyPart,
+						ContentSettings:  e.ContentSettings,
 					},
 				})
 			}
@@ -0,0 +1,536 @@
+package clientcache
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/internal/lru"
+	"golang.org/x/crypto/argon2"
+)
+
+type ClientCache struct {
+	hashes    *lru.Cache[string, string]
+	hashMutex sync.Mutex
+
+	// ids already checked for data left under previous salts
+	rehashed *lru.Cache[string, struct{}]
+
+	likePageMap       map[string]map[string]struct{}
+	viewPageMap       map[string]map[string]struct{}
+	pageMutexMap      map[string]*sync.RWMutex
+	pageMutexMapMutex sync.Mutex
+
+	// page to reaction to the set of clients who put it
+	reactionPageMap map[string]map[string]map[string]struct{}
+	reactionsMutex  sync.RWMutex
+
+	// changes made since the last flush, true for added pairs and false for removed ones
+	likeChanges     map[statPair]bool
+	viewChanges     map[statPair]bool
+	dailyChanges    map[dailyKey]DailyStat
+	reactionChanges map[reactionKey]bool
+	changesMutex    sync.Mutex
+	flushMutex      sync.Mutex
+
+	stop    chan struct{}
+	stopped chan struct{}
+
+	salt          []byte
+	previousSalts [][]byte
+	db            *sql.DB
+}
+
+type statPair struct {
+	page string
+	hash string
+}
+
+// NewClientCache loads likes and views into memory and writes the changed
+// pairs back to the db every flushInterval. Pairs hashed with one of the
+// previousSalts are moved to the current salt by Rehash
+func NewClientCache(db *sql.DB, salt []byte, previousSalts [][]byte, flushInterval time.Duration) (*ClientCache, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("fail to init transaction with db to fill cache: %w", err)
+	}
+	slog.Debug("began db transaction", slog.String("method", "NewClientCache"))
+
+	rows, err := tx.Query("select * from blog_likes;")
+	if err != nil {
+		tx.Rollback()
+		slog.Debug("ended db transaction", slog.String("method", "NewClientCache"))
+		return nil, fmt.Errorf("fail to query db for blog_likes to fill cache: %w", err)
+	}
+
+	likePageMap := make(map[string]map[string]struct{})
+	viewPageMap := make(map[string]map[string]struct{})
+	pageMutexMap := make(map[string]*sync.RWMutex)
+
+	for rows.Next() {
+		var pageRef string
+		var userId []byte
+		if err = rows.Scan(&pageRef, &userId); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("fail scanning blog_likes to fill cache: %w", err)
+		}
+		userIdString := base64.RawStdEncoding.EncodeToString(userId)
+		if _, ok := likePageMap[pageRef]; !ok {
+			likePageMap[pageRef] = make(map[string]struct{})
+			viewPageMap[pageRef] = make(map[string]struct{})
+			pageMutexMap[pageRef] = &sync.RWMutex{}
+		}
+		likePageMap[pageRef][userIdString] = struct{}{}
+		viewPageMap[pageRef][userIdString] = struct{}{}
+	}
+
+	rows, err = tx.Query("select * from blog_views;")
+	if err != nil {
+		tx.Rollback()
+		slog.Debug("ended db transaction", slog.String("method", "NewClientCache"))
+		return nil, fmt.Errorf("fail to query db for blog_views to fill cache: %w", err)
+	}
+
+	for rows.Next() {
+		var pageRef string
+		var userId []byte
+		if err = rows.Scan(&pageRef, &userId); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("fail scanning blog_views to fill cache: %w", err)
+		}
+		userIdString := base64.RawStdEncoding.EncodeToString(userId)
+		if _, ok := viewPageMap[pageRef]; !ok {
+			viewPageMap[pageRef] = make(map[string]struct{})
+			pageMutexMap[pageRef] = &sync.RWMutex{}
+		}
+		viewPageMap[pageRef][userIdString] = struct{}{}
+	}
+
+	reactionPageMap, err := loadReactions(tx)
+	if err != nil {
+		tx.Rollback()
+		slog.Debug("ended db transaction", slog.String("method", "NewClientCache"))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		slog.Debug("ended db transaction", slog.String("method", "NewClientCache"))
+		return nil, fmt.Errorf("fail to commit transaction in db: %w", err)
+	}
+	slog.Debug("ended db transaction", slog.String("method", "NewClientCache"))
+
+	c := &ClientCache{
+		hashes:          lru.New[string, string](lru.ClientCapacity),
+		rehashed:        lru.New[string, struct{}](lru.ClientCapacity),
+		likePageMap:     likePageMap,
+		viewPageMap:     viewPageMap,
+		pageMutexMap:    pageMutexMap,
+		reactionPageMap: reactionPageMap,
+		likeChanges:     make(map[statPair]bool),
+		viewChanges:     make(map[statPair]bool),
+		dailyChanges:    make(map[dailyKey]DailyStat),
+		reactionChanges: make(map[reactionKey]bool),
+		stop:            make(chan struct{}),
+		stopped:         make(chan struct{}),
+		salt:            salt,
+		previousSalts:   previousSalts,
+		db:              db,
+	}
+	go c.flushPeriodically(flushInterval)
+
+	return c, nil
+}
+
+// Close stops the periodic flush and writes the remaining changes
+func (c *ClientCache) Close() error {
+	close(c.stop)
+	<-c.stopped
+
+	if err := c.Flush(); err != nil {
+		return fmt.Errorf("fail to make the final flush: %w", err)
+	}
+	return nil
+}
+
+func (c *ClientCache) flushPeriodically(interval time.Duration) {
+	defer close(c.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if err := c.Flush(); err != nil {
+				slog.Error("failed to flush client cache", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// Flush writes the likes, views, reactions and daily stats changed since the last flush into the db.
+// If writing fails, the changes are kept for the next attempt unless they were
+// overridden in the meantime
+func (c *ClientCache) Flush() error {
+	c.flushMutex.Lock()
+	defer c.flushMutex.Unlock()
+
+	c.changesMutex.Lock()
+	likeChanges, viewChanges, dailyChanges, reactionChanges := c.likeChanges, c.viewChanges, c.dailyChanges, c.reactionChanges
+	c.likeChanges, c.viewChanges, c.dailyChanges = make(map[statPair]bool), make(map[statPair]bool), make(map[dailyKey]DailyStat)
+	c.reactionChanges = make(map[reactionKey]bool)
+	c.changesMutex.Unlock()
+
+	if len(likeChanges) == 0 && len(viewChanges) == 0 && len(dailyChanges) == 0 && len(reactionChanges) == 0 {
+		return nil
+	}
+
+	if err := c.saveChanges(likeChanges, viewChanges, dailyChanges, reactionChanges); err != nil {
+		c.changesMutex.Lock()
+		restoreChanges(c.likeChanges, likeChanges)
+		restoreChanges(c.viewChanges, viewChanges)
+		restoreDailyChanges(c.dailyChanges, dailyChanges)
+		restoreReactionChanges(c.reactionChanges, reactionChanges)
+		c.changesMutex.Unlock()
+		return err
+	}
+
+	slog.Debug("flushed client cache",
+		slog.Int("like_changes", len(likeChanges)),
+		slog.Int("view_changes", len(viewChanges)),
+		slog.Int("daily_changes", len(dailyChanges)),
+		slog.Int("reaction_changes", len(reactionChanges)))
+	return nil
+}
+
+func (c *ClientCache) saveChanges(likeChanges map[statPair]bool, viewChanges map[statPair]bool, dailyChanges map[dailyKey]DailyStat, reactionChanges map[reactionKey]bool) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("fail to init transaction with db to flush cache: %w", err)
+	}
+	slog.Debug("began db transaction", slog.String("method", "ClientCache.Flush"))
+	defer slog.Debug("ended db transaction", slog.String("method", "ClientCache.Flush"))
+
+	if err = applyChanges(tx, "blog_likes", likeChanges); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("fail to save blog_likes: %w", err)
+	}
+
+	if err = applyChanges(tx, "blog_views", viewChanges); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("fail to save blog_views: %w", err)
+	}
+
+	if err = applyDailyChanges(tx, dailyChanges); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("fail to save blog_daily_stats: %w", err)
+	}
+
+	if err = applyReactionChanges(tx, reactionChanges); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("fail to save blog_reactions: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("fail to commit all the changes related to cache: %w", err)
+	}
+	return nil
+}
+
+func restoreChanges(current map[statPair]bool, failed map[statPair]bool) {
+	for pair, added := range failed {
+		if _, ok := current[pair]; !ok {
+			current[pair] = added
+		}
+	}
+}
+
+func (c *ClientCache) recordChange(changes map[statPair]bool, page string, hash string, added bool) {
+	c.changesMutex.Lock()
+	changes[statPair{page, hash}] = added
+	c.changesMutex.Unlock()
+}
+
+func (c *ClientCache) GetHash(id string) string {
+	if val, ok := c.hashes.Get(id); ok {
+		slog.Debug("gave an old hash", slog.String("hash", val))
+		return val
+	}
+
+	c.hashMutex.Lock()
+	defer c.hashMutex.Unlock()
+
+	if val, ok := c.hashes.Get(id); ok {
+		slog.Debug("gave a newly generated hash", slog.String("hash", val))
+		return val
+	}
+
+	val := hashWithSalt(id, c.salt)
+	c.hashes.Add(id, val)
+	slog.Debug("generated hash", slog.String("hash", val))
+	return val
+}
+
+func hashWithSalt(id string, salt []byte) string {
+	return base64.RawStdEncoding.EncodeToString(argon2.IDKey([]byte(id), salt, 1, 64*1024, 4, 32))
+}
+
+func (c *ClientCache) getPageMutex(page string) *sync.RWMutex {
+	c.pageMutexMapMutex.Lock()
+	defer c.pageMutexMapMutex.Unlock()
+
+	if mutex, ok := c.pageMutexMap[page]; ok {
+		return mutex
+	}
+
+	c.pageMutexMap[page] = &sync.RWMutex{}
+	return c.pageMutexMap[page]
+}
+
+func (c *ClientCache) GetLikeStatus(id string, page string) bool {
+	page = strings.Clone(page)
+
+	mutex := c.getPageMutex(page)
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if _, ok := c.likePageMap[page]; !ok {
+		return false
+	}
+	_, ok := c.likePageMap[page][c.GetHash(id)]
+	return ok
+}
+
+func (c *ClientCache) GetLikeCount(page string) int {
+	page = strings.Clone(page)
+
+	mutex := c.getPageMutex(page)
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if userSet, ok := c.likePageMap[page]; ok {
+		return len(userSet)
+	}
+	return 0
+}
+
+// LikeOn marks the page liked by the client. Likes are shared by the
+// translations of a post, lang only tells which one the daily stats count it for
+func (c *ClientCache) LikeOn(id string, page string, lang string) (alreadyLiked bool) {
+	page, lang = strings.Clone(page), strings.Clone(lang)
+	hash := c.GetHash(id)
+
+	mutex := c.getPageMutex(page)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if userSet, ok := c.likePageMap[page]; ok {
+		_, alreadyLiked = userSet[hash]
+	} else {
+		c.likePageMap[page] = make(map[string]struct{})
+	}
+	c.likePageMap[page][hash] = struct{}{}
+
+	if !alreadyLiked {
+		c.recordChange(c.likeChanges, page, hash, true)
+		c.recordDaily(page, lang, DailyStat{Likes: 1})
+	}
+	return
+}
+
+func (c *ClientCache) LikeOff(id string, page string, lang string) (alreadyUnliked bool) {
+	page, lang = strings.Clone(page), strings.Clone(lang)
+	hash := c.GetHash(id)
+
+	mutex := c.getPageMutex(page)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, ok := c.likePageMap[page]; !ok {
+		return true
+	}
+	if _, ok := c.likePageMap[page][hash]; !ok {
+		return true
+	}
+
+	delete(c.likePageMap[page], hash)
+	c.recordChange(c.likeChanges, page, hash, false)
+	c.recordDaily(page, lang, DailyStat{Unlikes: 1})
+	return false
+}
+
+func (c *ClientCache) GetViewStatus(id string, page string) bool {
+	page = strings.Clone(page)
+
+	mutex := c.getPageMutex(page)
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if _, ok := c.viewPageMap[page]; !ok {
+		return false
+	}
+	_, ok := c.viewPageMap[page][c.GetHash(id)]
+	return ok
+}
+
+func (c *ClientCache) GetViewCount(page string) int {
+	page = strings.Clone(page)
+
+	mutex := c.getPageMutex(page)
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if userSet, ok := c.viewPageMap[page]; ok {
+		return len(userSet)
+	}
+	return 0
+}
+
+func (c *ClientCache) View(id string, page string, lang string) {
+	page, lang = strings.Clone(page), strings.Clone(lang)
+	hash := c.GetHash(id)
+
+	mutex := c.getPageMutex(page)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, ok := c.viewPageMap[page]; !ok {
+		c.viewPageMap[page] = make(map[string]struct{})
+	}
+	if _, ok := c.viewPageMap[page][hash]; ok {
+		return
+	}
+	c.viewPageMap[page][hash] = struct{}{}
+	c.recordChange(c.viewChanges, page, hash, true)
+	c.recordDaily(page, lang, DailyStat{NewViewers: 1})
+}
+
+// Migrate moves likes, views and reactions of one client to another, keeping
+// the pairs the target already has
+func (c *ClientCache) Migrate(fromId string, toId string) (moved int) {
+	return c.migrateHash(c.GetHash(fromId), c.GetHash(toId))
+}
+
+// Rehash moves likes and views the client left under previous salts to its
+// current hash. Every id is checked once while it stays in memory
+func (c *ClientCache) Rehash(id string) (moved int) {
+	if len(c.previousSalts) == 0 {
+		return 0
+	}
+	if _, ok := c.rehashed.Get(id); ok {
+		return 0
+	}
+	c.rehashed.Add(id, struct{}{})
+
+	hash := c.GetHash(id)
+	for _, salt := range c.previousSalts {
+		moved += c.migrateHash(hashWithSalt(id, salt), hash)
+	}
+	if moved > 0 {
+		slog.Debug("moved client stats to the current salt", slog.Int("moved", moved))
+	}
+	return moved
+}
+
+func (c *ClientCache) migrateHash(fromHash string, toHash string) (moved int) {
+	c.pageMutexMapMutex.Lock()
+	pages := make([]string, 0, len(c.pageMutexMap))
+	for page := range c.pageMutexMap {
+		pages = append(pages, page)
+	}
+	c.pageMutexMapMutex.Unlock()
+
+	for _, page := range pages {
+		mutex := c.getPageMutex(page)
+
+		// most pages were never seen by the client, so readers aren't blocked there
+		mutex.RLock()
+		_, liked := c.likePageMap[page][fromHash]
+		_, viewed := c.viewPageMap[page][fromHash]
+		mutex.RUnlock()
+		if !liked && !viewed {
+			continue
+		}
+
+		mutex.Lock()
+		moved += c.movePair(c.likePageMap, c.likeChanges, page, fromHash, toHash)
+		moved += c.movePair(c.viewPageMap, c.viewChanges, page, fromHash, toHash)
+		mutex.Unlock()
+	}
+	return moved + c.moveReactions(fromHash, toHash)
+}
+
+func (c *ClientCache) movePair(pageMap map[string]map[string]struct{}, changes map[statPair]bool, page string, fromHash string, toHash string) int {
+	userSet, ok := pageMap[page]
+	if !ok {
+		return 0
+	}
+	if _, ok := userSet[fromHash]; !ok {
+		return 0
+	}
+
+	delete(userSet, fromHash)
+	c.recordChange(changes, page, fromHash, false)
+	if _, ok := userSet[toHash]; !ok {
+		userSet[toHash] = struct{}{}
+		c.recordChange(changes, page, toHash, true)
+	}
+	return 1
+}
+
+// applyChanges inserts the added pairs into the table in batches and deletes
+// the removed ones
+func applyChanges(tx *sql.Tx, table string, changes map[statPair]bool) (err error) {
+	deleteStatement := fmt.Sprintf("DELETE FROM %s WHERE page_ref = ? AND user_id = ?;", table)
+	sqlStatementVars := make([]any, 0, 200)
+
+	for pair, added := range changes {
+		userId, err := base64.RawStdEncoding.DecodeString(pair.hash)
+		if err != nil {
+			slog.Warn("couldn't parse one of user hashes into bytes back", slog.String("hash", pair.hash), slog.String("error", err.Error()))
+			continue
+		}
+
+		if !added {
+			if _, err := tx.Exec(deleteStatement, pair.page, userId); err != nil {
+				return fmt.Errorf("fail to delete a pair from %s: %w", table, err)
+			}
+			continue
+		}
+
+		sqlStatementVars = append(sqlStatementVars, any(pair.page), any(userId))
+		if len(sqlStatementVars) < 200 {
+			continue
+		}
+
+		if err := batchInsert(tx, table, sqlStatementVars); err != nil {
+			return err
+		}
+		sqlStatementVars = make([]any, 0, 200)
+	}
+
+	if len(sqlStatementVars) > 0 {
+		return batchInsert(tx, table, sqlStatementVars)
+	}
+	return nil
+}
+
+func batchInsert(tx *sql.Tx, table string, sqlStatementVars []any) error {
+	sqlStatement := fmt.Sprintf(`
+	INSERT OR IGNORE INTO %s (page_ref, user_id)
+	VALUES %s(?, ?);
+	`, table, strings.Repeat("(?, ?), ", len(sqlStatementVars)/2-1))
+
+	if _, err := tx.Exec(sqlStatement, sqlStatementVars...); err != nil {
+		return fmt.Errorf("fail to insert blog stat pairs into %s: %w", table, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package clientcache
+
+import (
+	"database/sql"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// childDBEnv names the db file the test binary writes into when it is run as
+// the process killed by TestClientCacheSurvivesKill
+const childDBEnv = "CLIENTCACHE_TEST_CHILD_DB"
+
+var testMigrations = []string{
+	"1_create_stats_tables.up.sql",
+	"3_create_daily_stats_table.up.sql",
+	"6_create_reactions_table.up.sql",
+}
+
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// newTestDB creates a db file with the stats tables
+func newTestDB(t *testing.T) (db *sql.DB, path string) {
+	t.Helper()
+	path = t.TempDir() + "/stats.db"
+	db = openTestDB(t, path)
+
+	for _, name := range testMigrations {
+		migration, err := os.ReadFile("../../migrations/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = db.Exec(string(migration)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, path
+}
+
+// reopen loads a new cache from the db file, as a restarted server would
+func reopen(t *testing.T, path string) *ClientCache {
+	t.Helper()
+	cache, err := NewClientCache(openTestDB(t, path), []byte("salt"), nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func today(t *testing.T, cache *ClientCache, page string, lang string) DailyStat {
+	t.Helper()
+	series, err := cache.DailySeries(lang, []string{page}, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("expected a single day in the series, got %d", len(series))
+	}
+	return series[0]
+}
+
+// TestClientCacheChild records likes and views and waits to be killed, it only
+// runs as the child process of TestClientCacheSurvivesKill
+func TestClientCacheChild(t *testing.T) {
+	path := os.Getenv(childDBEnv)
+	if path == "" {
+		t.Skip("runs only as the child process of TestClientCacheSurvivesKill")
+	}
+
+	cache, err := NewClientCache(openTestDB(t, path), []byte("salt"), nil, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.View("alice", "first-post", "en")
+	cache.View("bob", "first-post", "en")
+	cache.View("alice", "second-post", "en")
+	cache.View("carol", "first-post", "ru")
+	cache.LikeOn("alice", "first-post", "en")
+	cache.LikeOn("bob", "first-post", "en")
+	cache.LikeOff("bob", "first-post", "en")
+
+	time.Sleep(time.Minute)
+}
+
+func TestClientCacheSurvivesKill(t *testing.T) {
+	db, path := newTestDB(t)
+
+	child := exec.Command(os.Args[0], "-test.run=^TestClientCacheChild$")
+	child.Env = append(os.Environ(), childDBEnv+"="+path)
+	if err := child.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer child.Process.Kill()
+
+	// the child is killed once a periodic flush wrote all of its changes
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		var views, likes int
+		db.QueryRow(`SELECT COUNT(*) FROM blog_views;`).Scan(&views)
+		db.QueryRow(`SELECT COUNT(*) FROM blog_likes;`).Scan(&likes)
+		if views == 4 && likes == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("child did not flush its changes in time, got %d views and %d likes", views, likes)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err := child.Process.Signal(syscall.SIGKILL); err != nil {
+		t.Fatal(err)
+	}
+	child.Wait()
+
+	restored := reopen(t, path)
+	if count := restored.GetViewCount("first-post"); count != 3 {
+		t.Fatalf("expected 3 views of first-post, got %d", count)
+	}
+	if count := restored.GetViewCount("second-post"); count != 1 {
+		t.Fatalf("expected 1 view of second-post, got %d", count)
+	}
+	if count := restored.GetLikeCount("first-post"); count != 1 || !restored.GetLikeStatus("alice", "first-post") {
+		t.Fatalf("expected first-post to be liked by alice only, got %d likes", count)
+	}
+	if stat := today(t, restored, "first-post", "en"); stat.NewViewers != 2 || stat.Likes != 2 || stat.Unlikes != 1 {
+		t.Fatalf("expected 2 new viewers, 2 likes and 1 unlike of first-post in en today, got %+v", stat)
+	}
+	if stat := today(t, restored, "first-post", "ru"); stat.NewViewers != 1 || stat.Likes != 0 {
+		t.Fatalf("expected 1 new viewer and no likes of first-post in ru today, got %+v", stat)
+	}
+}
+
+func TestClientCacheRetriesFailedFlush(t *testing.T) {
+	db, path := newTestDB(t)
+
+	cache, err := NewClientCache(db, []byte("salt"), nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.View("alice", "first-post", "en")
+	cache.LikeOn("alice", "first-post", "en")
+
+	// the daily stats can't be written, so nothing of the flush is
+	if _, err = db.Exec(`ALTER TABLE blog_daily_stats RENAME TO blog_daily_stats_away;`); err != nil {
+		t.Fatal(err)
+	}
+	if err = cache.Flush(); err == nil {
+		t.Fatal("expected the flush to fail without the daily stats table")
+	}
+	if _, err = db.Exec(`ALTER TABLE blog_daily_stats_away RENAME TO blog_daily_stats;`); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.View("bob", "first-post", "en")
+	cache.LikeOn("bob", "first-post", "en")
+	if err = cache.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := reopen(t, path)
+	if count := restored.GetLikeCount("first-post"); count != 2 {
+		t.Fatalf("expected the likes of the failed flush to be saved by the next one, got %d likes", count)
+	}
+	if count := restored.GetViewCount("first-post"); count != 2 {
+		t.Fatalf("expected the view of the failed flush to be saved by the next one, got %d views", count)
+	}
+	if stat := today(t, restored, "first-post", "en"); stat.NewViewers != 2 || stat.Likes != 2 {
+		t.Fatalf("expected 2 new viewers and 2 likes of first-post today, got %+v", stat)
+	}
+}
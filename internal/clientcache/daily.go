@@ -0,0 +1,127 @@
+package clientcache
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DailyStat is the activity on pages during a single UTC day. NewViewers are
+// the clients that opened the page that day for the first time ever, a client
+// coming back on another day is not counted again
+type DailyStat struct {
+	Date       string `json:"date"`
+	NewViewers int    `json:"newViewers"`
+	Likes      int    `json:"likes"`
+	Unlikes    int    `json:"unlikes"`
+}
+
+// dailyKey tells the languages of a page apart, as the same codename is used
+// by every translation of a post
+type dailyKey struct {
+	page string
+	lang string
+	day  string
+}
+
+func (s *DailyStat) add(other DailyStat) {
+	s.NewViewers += other.NewViewers
+	s.Likes += other.Likes
+	s.Unlikes += other.Unlikes
+}
+
+func (c *ClientCache) recordDaily(page string, lang string, delta DailyStat) {
+	key := dailyKey{page, lang, time.Now().UTC().Format(time.DateOnly)}
+
+	c.changesMutex.Lock()
+	stat := c.dailyChanges[key]
+	stat.add(delta)
+	c.dailyChanges[key] = stat
+	c.changesMutex.Unlock()
+}
+
+func restoreDailyChanges(current map[dailyKey]DailyStat, failed map[dailyKey]DailyStat) {
+	for key, delta := range failed {
+		stat := current[key]
+		stat.add(delta)
+		current[key] = stat
+	}
+}
+
+func applyDailyChanges(tx *sql.Tx, changes map[dailyKey]DailyStat) error {
+	for key, delta := range changes {
+		if _, err := tx.Exec(`
+		INSERT INTO blog_daily_stats (page_ref, lang, day, new_viewers, likes, unlikes)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (page_ref, lang, day) DO UPDATE SET
+			new_viewers = new_viewers + excluded.new_viewers,
+			likes = likes + excluded.likes,
+			unlikes = unlikes + excluded.unlikes;
+		`, key.page, key.lang, key.day, delta.NewViewers, delta.Likes, delta.Unlikes); err != nil {
+			return fmt.Errorf("fail to upsert daily stats of '%s' in '%s' for %s: %w", key.page, key.lang, key.day, err)
+		}
+	}
+	return nil
+}
+
+// DailySeries sums the daily stats of the pages in the language for every day
+// between from and to inclusive, days without any activity are filled with zeroes
+func (c *ClientCache) DailySeries(lang string, pages []string, from time.Time, to time.Time) ([]DailyStat, error) {
+	fromDay, toDay := from.UTC().Format(time.DateOnly), to.UTC().Format(time.DateOnly)
+	totals := make(map[string]DailyStat)
+
+	if len(pages) > 0 {
+		args := make([]any, 0, len(pages)+3)
+		args = append(args, lang, fromDay, toDay)
+		for _, page := range pages {
+			args = append(args, page)
+		}
+
+		rows, err := c.db.Query(fmt.Sprintf(`
+		SELECT day, SUM(new_viewers), SUM(likes), SUM(unlikes)
+		FROM blog_daily_stats
+		WHERE lang = ? AND day BETWEEN ? AND ? AND page_ref IN (%s)
+		GROUP BY day;
+		`, strings.TrimSuffix(strings.Repeat("?, ", len(pages)), ", ")), args...)
+		if err != nil {
+			return nil, fmt.Errorf("fail to query daily stats: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var stat DailyStat
+			if err := rows.Scan(&stat.Date, &stat.NewViewers, &stat.Likes, &stat.Unlikes); err != nil {
+				return nil, fmt.Errorf("fail scanning daily stats: %w", err)
+			}
+			totals[stat.Date] = stat
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("fail reading daily stats: %w", err)
+		}
+	}
+
+	pageSet := make(map[string]struct{}, len(pages))
+	for _, page := range pages {
+		pageSet[page] = struct{}{}
+	}
+
+	c.changesMutex.Lock()
+	for key, delta := range c.dailyChanges {
+		if _, ok := pageSet[key.page]; !ok || key.lang != lang || key.day < fromDay || key.day > toDay {
+			continue
+		}
+		stat := totals[key.day]
+		stat.add(delta)
+		totals[key.day] = stat
+	}
+	c.changesMutex.Unlock()
+
+	series := make([]DailyStat, 0)
+	for day := from.UTC().Truncate(24 * time.Hour); day.Format(time.DateOnly) <= toDay; day = day.AddDate(0, 0, 1) {
+		stat := totals[day.Format(time.DateOnly)]
+		stat.Date = day.Format(time.DateOnly)
+		series = append(series, stat)
+	}
+	return series, nil
+}
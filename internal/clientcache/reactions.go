@@ -0,0 +1,169 @@
+package clientcache
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+type reactionKey struct {
+	page     string
+	reaction string
+	hash     string
+}
+
+func loadReactions(tx *sql.Tx) (map[string]map[string]map[string]struct{}, error) {
+	rows, err := tx.Query("select page_ref, user_id, reaction from blog_reactions;")
+	if err != nil {
+		return nil, fmt.Errorf("fail to query db for blog_reactions to fill cache: %w", err)
+	}
+	defer rows.Close()
+
+	reactionPageMap := make(map[string]map[string]map[string]struct{})
+	for rows.Next() {
+		var pageRef, reaction string
+		var userId []byte
+		if err = rows.Scan(&pageRef, &userId, &reaction); err != nil {
+			return nil, fmt.Errorf("fail scanning blog_reactions to fill cache: %w", err)
+		}
+		if _, ok := reactionPageMap[pageRef]; !ok {
+			reactionPageMap[pageRef] = make(map[string]map[string]struct{})
+		}
+		if _, ok := reactionPageMap[pageRef][reaction]; !ok {
+			reactionPageMap[pageRef][reaction] = make(map[string]struct{})
+		}
+		reactionPageMap[pageRef][reaction][base64.RawStdEncoding.EncodeToString(userId)] = struct{}{}
+	}
+	return reactionPageMap, rows.Err()
+}
+
+// GetReactions returns the reactions the client put on the page
+func (c *ClientCache) GetReactions(id string, page string) map[string]bool {
+	hash := c.GetHash(id)
+
+	c.reactionsMutex.RLock()
+	defer c.reactionsMutex.RUnlock()
+
+	picked := make(map[string]bool)
+	for reaction, userSet := range c.reactionPageMap[page] {
+		if _, ok := userSet[hash]; ok {
+			picked[reaction] = true
+		}
+	}
+	return picked
+}
+
+// GetReactionCounts returns the number of clients per reaction put on the page
+func (c *ClientCache) GetReactionCounts(page string) map[string]int {
+	c.reactionsMutex.RLock()
+	defer c.reactionsMutex.RUnlock()
+
+	counts := make(map[string]int)
+	for reaction, userSet := range c.reactionPageMap[page] {
+		if len(userSet) > 0 {
+			counts[reaction] = len(userSet)
+		}
+	}
+	return counts
+}
+
+func (c *ClientCache) ReactionOn(id string, page string, reaction string) (alreadyOn bool) {
+	page, reaction = strings.Clone(page), strings.Clone(reaction)
+	hash := c.GetHash(id)
+
+	c.reactionsMutex.Lock()
+	defer c.reactionsMutex.Unlock()
+
+	if _, ok := c.reactionPageMap[page]; !ok {
+		c.reactionPageMap[page] = make(map[string]map[string]struct{})
+	}
+	userSet, ok := c.reactionPageMap[page][reaction]
+	if !ok {
+		userSet = make(map[string]struct{})
+		c.reactionPageMap[page][reaction] = userSet
+	}
+
+	if _, alreadyOn = userSet[hash]; !alreadyOn {
+		userSet[hash] = struct{}{}
+		c.recordReactionChange(reactionKey{page, reaction, hash}, true)
+	}
+	return alreadyOn
+}
+
+func (c *ClientCache) ReactionOff(id string, page string, reaction string) (alreadyOff bool) {
+	hash := c.GetHash(id)
+
+	c.reactionsMutex.Lock()
+	defer c.reactionsMutex.Unlock()
+
+	userSet, ok := c.reactionPageMap[page][reaction]
+	if !ok {
+		return true
+	}
+	if _, ok := userSet[hash]; !ok {
+		return true
+	}
+
+	delete(userSet, hash)
+	c.recordReactionChange(reactionKey{page, reaction, hash}, false)
+	return false
+}
+
+func (c *ClientCache) recordReactionChange(key reactionKey, added bool) {
+	c.changesMutex.Lock()
+	c.reactionChanges[key] = added
+	c.changesMutex.Unlock()
+}
+
+// moveReactions moves every reaction of one client to another, keeping the
+// ones the target already has
+func (c *ClientCache) moveReactions(fromHash string, toHash string) (moved int) {
+	c.reactionsMutex.Lock()
+	defer c.reactionsMutex.Unlock()
+
+	for page, reactions := range c.reactionPageMap {
+		for reaction, userSet := range reactions {
+			if _, ok := userSet[fromHash]; !ok {
+				continue
+			}
+
+			delete(userSet, fromHash)
+			c.recordReactionChange(reactionKey{page, reaction, fromHash}, false)
+			if _, ok := userSet[toHash]; !ok {
+				userSet[toHash] = struct{}{}
+				c.recordReactionChange(reactionKey{page, reaction, toHash}, true)
+			}
+			moved++
+		}
+	}
+	return moved
+}
+
+func restoreReactionChanges(current map[reactionKey]bool, failed map[reactionKey]bool) {
+	for key, added := range failed {
+		if _, ok := current[key]; !ok {
+			current[key] = added
+		}
+	}
+}
+
+func applyReactionChanges(tx *sql.Tx, changes map[reactionKey]bool) error {
+	for key, added := range changes {
+		userId, err := base64.RawStdEncoding.DecodeString(key.hash)
+		if err != nil {
+			slog.Warn("couldn't parse one of user hashes into bytes back", slog.String("hash", key.hash), slog.String("error", err.Error()))
+			continue
+		}
+
+		statement := `INSERT OR IGNORE INTO blog_reactions (page_ref, user_id, reaction) VALUES (?, ?, ?);`
+		if !added {
+			statement = `DELETE FROM blog_reactions WHERE page_ref = ? AND user_id = ? AND reaction = ?;`
+		}
+		if _, err := tx.Exec(statement, key.page, userId, key.reaction); err != nil {
+			return fmt.Errorf("fail to save reaction '%s' on '%s': %w", key.reaction, key.page, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package comments
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// newMarkdown builds the pipeline comments are rendered with. Compared to the
+// one used for posts it has no headings, raw HTML, images or custom blocks,
+// and every link is marked as user generated
+func newMarkdown() goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithParser(parser.NewParser(
+			parser.WithBlockParsers(
+				util.Prioritized(parser.NewListParser(), 300),
+				util.Prioritized(parser.NewListItemParser(), 400),
+				util.Prioritized(parser.NewFencedCodeBlockParser(), 700),
+				util.Prioritized(parser.NewBlockquoteParser(), 800),
+				util.Prioritized(parser.NewParagraphParser(), 1000),
+			),
+			parser.WithInlineParsers(
+				util.Prioritized(parser.NewCodeSpanParser(), 100),
+				util.Prioritized(parser.NewLinkParser(), 200),
+				util.Prioritized(parser.NewAutoLinkParser(), 300),
+				util.Prioritized(parser.NewEmphasisParser(), 500),
+			),
+			parser.WithASTTransformers(
+				util.Prioritized(&commentTransformer{}, 1000),
+			),
+		)),
+		goldmark.WithExtensions(
+			extension.Strikethrough,
+			extension.Linkify,
+		),
+		goldmark.WithRendererOptions(
+			html.WithHardWraps(),
+			html.WithXHTML(),
+		),
+	)
+}
+
+type commentTransformer struct{}
+
+func (t *commentTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	images := make([]*ast.Image, 0)
+
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.Paragraph:
+			node.SetAttribute([]byte("class"), []byte("text-base/[1.75] font-gentium tracking-[.0125rem] mb-2 last:mb-0"))
+		case *ast.List:
+			if node.IsOrdered() {
+				node.SetAttribute([]byte("class"), []byte("list-decimal list-inside mb-2 pl-4"))
+			} else {
+				node.SetAttribute([]byte("class"), []byte("list-disc list-inside mb-2 pl-4"))
+			}
+		case *ast.Blockquote:
+			node.SetAttribute([]byte("class"), []byte("border-l-[0.125rem] border-main-medium bg-paper bg-background-dark p-1 mb-2 italic font-thin"))
+		case *ast.CodeSpan:
+			node.SetAttribute([]byte("class"), []byte("bg-paper bg-background-dark"))
+		case *ast.FencedCodeBlock:
+			node.SetAttribute([]byte("class"), []byte("bg-paper bg-background-dark p-1 rounded-lg overflow-x-auto mb-2"))
+		case *ast.Link, *ast.AutoLink:
+			node.SetAttribute([]byte("class"), []byte("text-secondary hover:text-main-hard underline"))
+			node.SetAttribute([]byte("rel"), []byte("nofollow ugc noopener"))
+		case *ast.Image:
+			images = append(images, node)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	// images are shown as plain links to them
+	for _, image := range images {
+		link := ast.NewLink()
+		link.Destination = image.Destination
+		link.SetAttribute([]byte("class"), []byte("text-secondary hover:text-main-hard underline"))
+		link.SetAttribute([]byte("rel"), []byte("nofollow ugc noopener"))
+		for child := image.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			link.AppendChild(link, child)
+			child = next
+		}
+		image.Parent().ReplaceChild(image.Parent(), image, link)
+	}
+}
+
+// Render converts the comment source into sanitized HTML
+func (s *Store) Render(source string) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := s.md.Convert([]byte(source), &buf); err != nil {
+		return "", fmt.Errorf("fail to render comment: %w", err)
+	}
+	return template.HTML(buf.String()), nil
+}
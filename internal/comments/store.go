@@ -0,0 +1,180 @@
+package comments
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"time"
+
+	"github.com/yuin/goldmark"
+)
+
+type Status string
+
+const (
+	Pending  Status = "pending"
+	Approved Status = "approved"
+	Rejected Status = "rejected"
+)
+
+const (
+	// MaxDepth is the deepest reply level, replies to comments on it are
+	// attached to their parent instead
+	MaxDepth      = 3
+	MaxBodyLength = 4000
+	MaxNameLength = 48
+)
+
+var (
+	ErrNotFound      = errors.New("comment not found")
+	ErrInvalidParent = errors.New("comment can't be replied to")
+)
+
+type Comment struct {
+	Id         int64
+	Page       string
+	Lang       string
+	ParentId   int64
+	Depth      int
+	Email      string
+	AuthorName string
+	Body       string
+	Html       template.HTML
+	Status     Status
+	CreatedAt  time.Time
+	Replies    []*Comment
+}
+
+// Store keeps comments of blog pages, every comment waits for moderation
+// before it is shown
+type Store struct {
+	db *sql.DB
+	md goldmark.Markdown
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db, md: newMarkdown()}
+}
+
+// Add saves a new pending comment and returns its id. A reply must belong to
+// an approved comment on the same page
+func (s *Store) Add(comment *Comment) (id int64, err error) {
+	var parentId any
+	if comment.ParentId != 0 {
+		parent, err := s.Get(comment.ParentId)
+		if errors.Is(err, ErrNotFound) {
+			return 0, ErrInvalidParent
+		}
+		if err != nil {
+			return 0, err
+		}
+		if parent.Status != Approved || parent.Page != comment.Page || parent.Lang != comment.Lang {
+			return 0, ErrInvalidParent
+		}
+
+		if parent.Depth >= MaxDepth {
+			comment.ParentId, comment.Depth = parent.ParentId, parent.Depth
+		} else {
+			comment.Depth = parent.Depth + 1
+		}
+		parentId = comment.ParentId
+	}
+
+	comment.Status = Pending
+	comment.CreatedAt = time.Now()
+	result, err := s.db.Exec(`INSERT INTO comment_table(page_ref, lang, parent_id, depth, email, author_name, body, status, created_at)
+  VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		comment.Page, comment.Lang, parentId, comment.Depth, comment.Email, comment.AuthorName, comment.Body, comment.Status, comment.CreatedAt.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("fail to insert comment: %w", err)
+	}
+
+	if comment.Id, err = result.LastInsertId(); err != nil {
+		return 0, fmt.Errorf("fail to get id of the new comment: %w", err)
+	}
+	return comment.Id, nil
+}
+
+func (s *Store) Get(id int64) (*Comment, error) {
+	comments, err := s.query(`WHERE comment_id=?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, ErrNotFound
+	}
+	return comments[0], nil
+}
+
+// Thread returns the approved comments of the page as a tree, oldest first
+func (s *Store) Thread(page string, lang string) ([]*Comment, error) {
+	comments, err := s.query(`WHERE page_ref=? AND lang=? AND status=? ORDER BY created_at, comment_id`, page, lang, Approved)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[int64]*Comment, len(comments))
+	for _, comment := range comments {
+		byId[comment.Id] = comment
+	}
+
+	roots := make([]*Comment, 0)
+	for _, comment := range comments {
+		if parent, ok := byId[comment.ParentId]; ok {
+			parent.Replies = append(parent.Replies, comment)
+		} else if comment.ParentId == 0 {
+			roots = append(roots, comment)
+		}
+	}
+	return roots, nil
+}
+
+// Pending returns the moderation queue, oldest first
+func (s *Store) Pending() ([]*Comment, error) {
+	return s.query(`WHERE status=? ORDER BY created_at, comment_id`, Pending)
+}
+
+// Moderate approves or rejects a pending comment
+func (s *Store) Moderate(id int64, status Status) error {
+	result, err := s.db.Exec(`UPDATE comment_table SET status=? WHERE comment_id=? AND status=?;`, status, id, Pending)
+	if err != nil {
+		return fmt.Errorf("fail to moderate comment: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) query(condition string, args ...any) ([]*Comment, error) {
+	rows, err := s.db.Query(`SELECT comment_id, page_ref, lang, parent_id, depth, email, author_name, body, status, created_at
+  FROM comment_table `+condition+`;`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("fail to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := make([]*Comment, 0)
+	for rows.Next() {
+		var comment Comment
+		var parentId sql.NullInt64
+		var createdAt int64
+		if err := rows.Scan(&comment.Id, &comment.Page, &comment.Lang, &parentId, &comment.Depth, &comment.Email,
+			&comment.AuthorName, &comment.Body, &comment.Status, &createdAt); err != nil {
+			return nil, fmt.Errorf("fail scanning comments: %w", err)
+		}
+		comment.ParentId = parentId.Int64
+		comment.CreatedAt = time.Unix(createdAt, 0)
+
+		if comment.Html, err = s.Render(comment.Body); err != nil {
+			slog.Warn("failed to render comment", slog.Int64("comment_id", comment.Id), slog.String("error", err.Error()))
+		}
+		comments = append(comments, &comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fail reading comments: %w", err)
+	}
+	return comments, nil
+}
@@ -0,0 +1,97 @@
+package corpus
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/internal/blog"
+	"github.com/SayaAndy/saya-today-web/internal/mdcontext"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Corpus parses all posts of a language in a single pass shared by the indexes
+// built over them, parsing them again once the pass is older than ttl
+type Corpus struct {
+	blogClient blog.Client
+	md         goldmark.Markdown
+	ttl        time.Duration
+	mu         sync.Mutex
+	langs      map[string]*Snapshot
+}
+
+// Snapshot is the result of parsing every post of a language. Indexes keep the
+// snapshot they were built from to tell when they are outdated
+type Snapshot struct {
+	BuiltAt time.Time
+	Posts   []*Post
+}
+
+// Post is a blog post with the parser context it left, where extensions keep
+// what they collected about it
+type Post struct {
+	Page    *blog.Page
+	Context parser.Context
+}
+
+func NewCorpus(blogClient blog.Client, md goldmark.Markdown, ttl time.Duration) *Corpus {
+	return &Corpus{
+		blogClient: blogClient,
+		md:         md,
+		ttl:        ttl,
+		langs:      make(map[string]*Snapshot),
+	}
+}
+
+// Get returns the parsed posts of the language. If they can't be parsed again,
+// the stale snapshot is served, and an error is returned only if there is none
+func (c *Corpus) Get(lang string) (*Snapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot, ok := c.langs[lang]
+	if !ok || time.Since(snapshot.BuiltAt) > c.ttl {
+		newSnapshot, err := c.build(lang)
+		if err != nil {
+			if !ok {
+				return nil, err
+			}
+			slog.Warn("failed to parse blog posts again, serving the stale ones", slog.String("lang", lang), slog.String("error", err.Error()))
+		} else {
+			snapshot = newSnapshot
+			c.langs[lang] = snapshot
+		}
+	}
+
+	return snapshot, nil
+}
+
+func (c *Corpus) build(lang string) (*Snapshot, error) {
+	pages, err := c.blogClient.Scan(lang + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{BuiltAt: time.Now(), Posts: make([]*Post, 0, len(pages))}
+	for _, page := range pages {
+		_, markdown, err := c.blogClient.ReadFrontmatter(lang + "/" + page.FileName + ".md")
+		if err != nil {
+			slog.Warn("failed to read blog post while parsing the corpus",
+				slog.String("lang", lang),
+				slog.String("codename", page.FileName),
+				slog.String("error", err.Error()))
+			continue
+		}
+
+		pc := parser.NewContext()
+		mdcontext.SetLang(pc, lang)
+		mdcontext.SetCodename(pc, page.FileName)
+		c.md.Parser().Parse(text.NewReader(markdown), parser.WithContext(pc))
+
+		snapshot.Posts = append(snapshot.Posts, &Post{Page: page, Context: pc})
+	}
+
+	return snapshot, nil
+}
@@ -12,12 +12,15 @@ type GLightboxBlock struct {
 	Images    []GLightboxImage
 	Location  *time.Location
 	Namespace string
+	Sidecar   string
 }
 
 type GLightboxImage struct {
 	URL     string
 	Tags    []string
+	Alt     string
 	Caption []byte
+	Time    time.Time
 }
 
 var KindGLightboxBlock = ast.NewNodeKind("GLightboxBlock")
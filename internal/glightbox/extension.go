@@ -1,6 +1,8 @@
 package glightbox
 
 import (
+	"time"
+
 	"github.com/SayaAndy/saya-today-web/config"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/parser"
@@ -11,10 +13,11 @@ import (
 // Extension that combines parser and renderer
 type GLightboxExtension struct {
 	photoStorage config.PhotoStorageConfig
+	sidecarTtl   time.Duration
 }
 
-func NewGLightboxExtension(photoStorage config.PhotoStorageConfig) goldmark.Extender {
-	return &GLightboxExtension{photoStorage}
+func NewGLightboxExtension(photoStorage config.PhotoStorageConfig, sidecarTtl time.Duration) goldmark.Extender {
+	return &GLightboxExtension{photoStorage, sidecarTtl}
 }
 
 func (e *GLightboxExtension) Extend(m goldmark.Markdown) {
@@ -25,7 +28,7 @@ func (e *GLightboxExtension) Extend(m goldmark.Markdown) {
 	)
 	m.Renderer().AddOptions(
 		renderer.WithNodeRenderers(
-			util.Prioritized(NewGLightboxHTMLRenderer(e.photoStorage), 500),
+			util.Prioritized(NewGLightboxHTMLRenderer(e.photoStorage, e.sidecarTtl), 500),
 		),
 	)
 }
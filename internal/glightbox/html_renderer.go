@@ -3,12 +3,14 @@ package glightbox
 import (
 	"bytes"
 	"fmt"
-	"math/rand"
+	"log/slog"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/SayaAndy/saya-today-web/config"
+	"github.com/SayaAndy/saya-today-web/internal/htmlid"
 	"github.com/SayaAndy/saya-today-web/internal/tailwind"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
@@ -23,9 +25,10 @@ type GLightboxHTMLRenderer struct {
 	md            goldmark.Markdown
 	anchorMatchRe *regexp.Regexp
 	photoStorage  config.PhotoStorageConfig
+	sidecars      *sidecarCache
 }
 
-func NewGLightboxHTMLRenderer(photoStorage config.PhotoStorageConfig, opts ...html.Option) renderer.NodeRenderer {
+func NewGLightboxHTMLRenderer(photoStorage config.PhotoStorageConfig, sidecarTtl time.Duration, opts ...html.Option) renderer.NodeRenderer {
 	r := &GLightboxHTMLRenderer{
 		Config: html.NewConfig(),
 		md: goldmark.New(
@@ -48,6 +51,7 @@ func NewGLightboxHTMLRenderer(photoStorage config.PhotoStorageConfig, opts ...ht
 		),
 		anchorMatchRe: regexp.MustCompile(`(?s)<\s*a(\s+[^<]*)(href\s*=\s*["'].*?["'])\s*([^<]*)>(.*?)<\s*\/\s*a\s*>`),
 		photoStorage:  photoStorage,
+		sidecars:      newSidecarCache(sidecarTtl),
 	}
 	for _, opt := range opts {
 		opt.SetHTMLOption(&r.Config)
@@ -67,15 +71,48 @@ func (r *GLightboxHTMLRenderer) renderGLightbox(w util.BufWriter, source []byte,
 			return ast.WalkContinue, nil
 		}
 
-		galleryID := generateDivId(8)
+		galleryID := htmlid.New(8)
+
+		sidecar := map[string]SidecarEntry{}
+		if gallery.Sidecar != "" {
+			var err error
+			if sidecar, err = r.sidecars.get(fmt.Sprintf(r.photoStorage.Full.BaseUrl, gallery.Namespace+gallery.Sidecar)); err != nil {
+				slog.Warn("failed to load gallery sidecar", slog.String("sidecar", gallery.Namespace+gallery.Sidecar), slog.String("error", err.Error()))
+			}
+		}
 
 		var elements []string
+		var undatedImages []string
 		for i, img := range gallery.Images {
 			fullImageUrl := gallery.Namespace + img.URL
 			imageUrlSegments := strings.Split(fullImageUrl, ".")
 			imageUrlWithoutExt := strings.Join(imageUrlSegments[:len(imageUrlSegments)-1], ".")
-			imageUrlParts := strings.Split(imageUrlWithoutExt, "/")
-			imageNameParts := strings.Split(imageUrlParts[len(imageUrlParts)-1], "-")
+
+			sidecarEntry := sidecar[img.URL]
+			if img.Alt == "" {
+				img.Alt = sidecarEntry.Alt
+			}
+			if len(img.Caption) == 0 {
+				img.Caption = []byte(sidecarEntry.Caption)
+			}
+
+			dayDate, dated := img.Time, !img.Time.IsZero()
+			if !dated && sidecarEntry.Time != "" {
+				var err error
+				dayDate, err = ParseTimestamp(sidecarEntry.Time, gallery.Location)
+				dated = err == nil
+			}
+			if !dated {
+				dayDate, dated = timestampFromFileName(fullImageUrl, gallery.Location)
+			}
+			if !dated {
+				undatedImages = append(undatedImages, fullImageUrl)
+			}
+
+			dataTitleAttribute := ""
+			if dated {
+				dataTitleAttribute = fmt.Sprintf(`data-title="%s"`, dayDate.In(gallery.Location).Format("2006-01-02 15:04:05 -07:00"))
+			}
 
 			var captionBuf bytes.Buffer
 			captionHTML := img.Caption
@@ -86,9 +123,6 @@ func (r *GLightboxHTMLRenderer) renderGLightbox(w util.BufWriter, source []byte,
 				captionHTML = bytes.TrimSuffix(captionHTML, []byte("</p>"))
 			}
 
-			dayDate, _ := time.Parse("20060102 150405", imageNameParts[len(imageNameParts)-2]+" "+imageNameParts[len(imageNameParts)-1])
-			dayDate = dayDate.In(gallery.Location)
-
 			glightboxDescId := ""
 			if len(captionHTML) != 0 {
 				glightboxDescId = fmt.Sprintf("glightbox-desc-%s-%d", galleryID, i)
@@ -111,34 +145,56 @@ func (r *GLightboxHTMLRenderer) renderGLightbox(w util.BufWriter, source []byte,
 				tagClassList = append(tagClassList, "grid-tooltip")
 			}
 
+			sizes := r.photoStorage.Sizes
+			if slices.Contains(img.Tags, "2x") {
+				sizes = r.photoStorage.Sizes2x
+			}
+			sizesAttribute := ""
+			if sizes != "" {
+				sizesAttribute = fmt.Sprintf(`sizes="%s"`, util.EscapeHTML([]byte(sizes)))
+			}
+
+			placeholderAttributes := ""
+			if sidecarEntry.Width > 0 && sidecarEntry.Height > 0 {
+				placeholderAttributes = fmt.Sprintf(`width="%d" height="%d"`, sidecarEntry.Width, sidecarEntry.Height)
+			}
+			if style, err := placeholderStyle(sidecarEntry); err != nil {
+				slog.Warn("failed to build placeholder of a gallery image", slog.String("image", fullImageUrl), slog.String("error", err.Error()))
+			} else if style != "" {
+				placeholderAttributes += fmt.Sprintf(` style="%s"`, style)
+			}
+
+			sourceElements := make([]string, 0)
+			for _, format := range r.photoStorage.AlternativeFormats() {
+				sourceElements = append(sourceElements, fmt.Sprintf(`<source type="%s" srcset="%s" %s />`,
+					config.FormatMimeType(format), r.photoStorage.Srcset(format, imageUrlWithoutExt), sizesAttribute))
+			}
+
 			anchorlessCaptionHTML := r.anchorMatchRe.ReplaceAll(captionHTML, []byte("<span class=\"linklike\" $1 $3>$4</span>"))
 
 			elements = append(elements, fmt.Sprintf(`
 	<a href="%[7]s" class="glightbox grid-item %[1]s grid-item-%[2]s p-1"
-	    data-gallery="gallery" data-title="%[3]s" %[4]s>
+	    data-gallery="gallery" %[3]s %[4]s>
 		<picture>
-			<source media="(width < 800px)" srcset="%[8]s" />
-			<source media="(width < 2400px)" srcset="%[9]s" />
-			<source media="(width < 3200px)" srcset="%[10]s" />
-			<source media="(width < 4000px)" srcset="%[11]s" />
-			<source media="(width >= 4000px)" srcset="%[12]s" />
-			<img src="%[9]s" />
+			%[8]s
+			<img src="%[9]s" srcset="%[10]s" %[11]s alt="%[12]s" %[13]s />
 		</picture>
 		<span class="grid-tooltip-text"><p>%[5]s</p></span>
 		<span class="grid-item-index">%[6]d</span>
 	</a>`,
 				strings.Join(tagClassList, " "),
 				galleryID,
-				dayDate.Format("2006-01-02 15:04:05 -07:00"),
+				dataTitleAttribute,
 				dataDescriptionAttribute,
 				anchorlessCaptionHTML,
 				i+1,
 				fmt.Sprintf(r.photoStorage.Full.BaseUrl, fullImageUrl),
-				fmt.Sprintf(r.photoStorage.Thumbnail1600p.BaseUrl, imageUrlWithoutExt),
-				fmt.Sprintf(r.photoStorage.Thumbnail1200p.BaseUrl, imageUrlWithoutExt),
-				fmt.Sprintf(r.photoStorage.Thumbnail800p.BaseUrl, imageUrlWithoutExt),
-				fmt.Sprintf(r.photoStorage.Thumbnail560p.BaseUrl, imageUrlWithoutExt),
-				fmt.Sprintf(r.photoStorage.Thumbnail320p.BaseUrl, imageUrlWithoutExt),
+				strings.Join(sourceElements, "\n\t\t\t"),
+				r.photoStorage.VariantUrl(1200, imageUrlWithoutExt),
+				r.photoStorage.Srcset(r.photoStorage.DefaultFormat, imageUrlWithoutExt),
+				sizesAttribute,
+				string(util.EscapeHTML([]byte(img.Alt))),
+				placeholderAttributes,
 			))
 
 			if glightboxDescId != "" {
@@ -149,6 +205,10 @@ func (r *GLightboxHTMLRenderer) renderGLightbox(w util.BufWriter, source []byte,
 			}
 		}
 
+		if len(undatedImages) != 0 {
+			slog.Warn("failed to get timestamps of gallery images, their dates are omitted", slog.Any("images", undatedImages))
+		}
+
 		w.WriteString(strings.ReplaceAll(`
 <div class="items-center flex flex-col">
 	<hr class="border-t-[0.375rem] border-dotted border-main-hard my-2 w-24 ml-auto mr-auto">
@@ -190,13 +250,3 @@ func (r *GLightboxHTMLRenderer) renderGLightbox(w util.BufWriter, source []byte,
 
 	return ast.WalkContinue, nil
 }
-
-func generateDivId(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyz"
-	seededRand := rand.New(rand.NewSource(time.Now().UnixNano())) // Seed with current time
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[seededRand.Intn(len(charset))]
-	}
-	return string(b)
-}
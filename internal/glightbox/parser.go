@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/parser"
@@ -14,6 +15,10 @@ import (
 
 type GLightboxParser struct{}
 
+// imageFieldRegexp matches a single key=value field following the image url,
+// values with spaces are quoted, e.g. `alt="Two cats" time=2024-01-02T15:04:05`
+var imageFieldRegexp = regexp.MustCompile(`^(\w+)=("[^"]*"|\S+)\s*`)
+
 func NewGLightboxParser() parser.BlockParser {
 	return &GLightboxParser{}
 }
@@ -66,8 +71,16 @@ func (p *GLightboxParser) Continue(node ast.Node, reader text.Reader, pc parser.
 
 	gallery := node.(*GLightboxBlock)
 
+	if sidecar, ok := bytes.CutPrefix(trimmed, []byte("sidecar:")); ok {
+		gallery.Sidecar = string(bytes.TrimSpace(sidecar))
+		return parser.Continue | parser.NoChildren
+	}
+
+	// url | caption
+	// url | tags | caption
+	// the url may be followed by alt="..." and time=... fields
 	parts := bytes.SplitN(trimmed, []byte{'|'}, 3)
-	url := bytes.TrimSpace(parts[0])
+	url, alt, timestamp := parseImageFields(bytes.TrimSpace(parts[0]), gallery.Location)
 
 	caption := make([]byte, 0)
 	tagsRaw := ""
@@ -85,14 +98,49 @@ func (p *GLightboxParser) Continue(node ast.Node, reader text.Reader, pc parser.
 	}
 
 	gallery.Images = append(gallery.Images, GLightboxImage{
-		URL:     string(url),
+		URL:     url,
 		Tags:    tags,
+		Alt:     alt,
 		Caption: caption,
+		Time:    timestamp,
 	})
 
 	return parser.Continue | parser.NoChildren
 }
 
+// parseImageFields splits the url of a gallery line from its alt and time fields
+func parseImageFields(segment []byte, loc *time.Location) (url string, alt string, timestamp time.Time) {
+	urlEnd := bytes.IndexFunc(segment, unicode.IsSpace)
+	if urlEnd < 0 {
+		return string(segment), "", time.Time{}
+	}
+	url = string(segment[:urlEnd])
+
+	rest := bytes.TrimSpace(segment[urlEnd:])
+	for len(rest) > 0 {
+		field := imageFieldRegexp.FindSubmatch(rest)
+		if field == nil {
+			slog.Warn("invalid field of a gallery image", slog.String("url", url), slog.String("field", string(rest)))
+			break
+		}
+		rest = rest[len(field[0]):]
+
+		value := strings.Trim(string(field[2]), `"`)
+		switch key := string(field[1]); key {
+		case "alt":
+			alt = value
+		case "time":
+			var err error
+			if timestamp, err = ParseTimestamp(value, loc); err != nil {
+				slog.Warn("invalid timestamp of a gallery image", slog.String("url", url), slog.String("error", err.Error()))
+			}
+		default:
+			slog.Warn("unknown field of a gallery image", slog.String("url", url), slog.String("field", key))
+		}
+	}
+	return url, alt, timestamp
+}
+
 func (p *GLightboxParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
 }
 
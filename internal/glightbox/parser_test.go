@@ -0,0 +1,75 @@
+package glightbox
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// parseGallery parses the lines as the body of a gallery in UTC and returns
+// its images
+func parseGallery(t *testing.T, lines string) []GLightboxImage {
+	t.Helper()
+	md := goldmark.New(goldmark.WithParserOptions(
+		parser.WithBlockParsers(util.Prioritized(NewGLightboxParser(), 500)),
+	))
+
+	source := []byte("{Gallery:UTC}\n" + lines + "\n{/Gallery}\n")
+	doc := md.Parser().Parse(text.NewReader(source))
+
+	for node := doc.FirstChild(); node != nil; node = node.NextSibling() {
+		if gallery, ok := node.(*GLightboxBlock); ok {
+			return gallery.Images
+		}
+	}
+	t.Fatalf("no gallery in the document:\n%s", source)
+	return nil
+}
+
+func TestParserKeepsPipesOfLegacyCaption(t *testing.T) {
+	images := parseGallery(t, "cats/IMG-20240102-150405.jpg | cats, roof | Left | right, both asleep")
+	if len(images) != 1 {
+		t.Fatalf("expected 1 image, got %d", len(images))
+	}
+
+	image := images[0]
+	if image.URL != "cats/IMG-20240102-150405.jpg" {
+		t.Fatalf("unexpected url '%s'", image.URL)
+	}
+	if !slices.Equal(image.Tags, []string{"cats", "roof"}) {
+		t.Fatalf("unexpected tags %q", image.Tags)
+	}
+	if string(image.Caption) != "Left | right, both asleep" {
+		t.Fatalf("expected the whole caption after the tags, got '%s'", image.Caption)
+	}
+	if image.Alt != "" || !image.Time.IsZero() {
+		t.Fatalf("expected no alt and no timestamp, got '%s' and %s", image.Alt, image.Time)
+	}
+}
+
+func TestParserReadsExplicitFields(t *testing.T) {
+	images := parseGallery(t, `cats/sleeping.jpg alt="Two cats, asleep" time="2024-01-02 15:04" | cats | On the roof`+"\n"+
+		`cats/awake.jpg time=2024-01-03T08:00:00 | In the morning`)
+	if len(images) != 2 {
+		t.Fatalf("expected 2 images, got %d", len(images))
+	}
+
+	sleeping, awake := images[0], images[1]
+	if sleeping.URL != "cats/sleeping.jpg" || sleeping.Alt != "Two cats, asleep" || string(sleeping.Caption) != "On the roof" {
+		t.Fatalf("unexpected first image %+v", sleeping)
+	}
+	if want := time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC); !sleeping.Time.Equal(want) {
+		t.Fatalf("expected the first image taken at %s, got %s", want, sleeping.Time)
+	}
+	if awake.URL != "cats/awake.jpg" || awake.Alt != "" || string(awake.Caption) != "In the morning" {
+		t.Fatalf("unexpected second image %+v", awake)
+	}
+	if want := time.Date(2024, 1, 3, 8, 0, 0, 0, time.UTC); !awake.Time.Equal(want) {
+		t.Fatalf("expected the second image taken at %s, got %s", want, awake.Time)
+	}
+}
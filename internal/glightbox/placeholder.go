@@ -0,0 +1,49 @@
+package glightbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"regexp"
+	"strings"
+
+	"github.com/buckket/go-blurhash"
+)
+
+const placeholderWidth = 16
+
+var colorRe = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}){1,2}$`)
+
+// placeholderStyle returns the inline style showing a blurred preview or the
+// dominant color of the photo until it loads
+func placeholderStyle(entry SidecarEntry) (string, error) {
+	styles := make([]string, 0, 3)
+
+	if entry.Color != "" {
+		if !colorRe.MatchString(entry.Color) {
+			return "", fmt.Errorf("invalid dominant color '%s'", entry.Color)
+		}
+		styles = append(styles, "background-color: "+entry.Color)
+	}
+
+	if entry.Blurhash != "" {
+		height := placeholderWidth
+		if entry.Width > 0 && entry.Height > 0 {
+			height = max(1, placeholderWidth*entry.Height/entry.Width)
+		}
+
+		img, err := blurhash.Decode(entry.Blurhash, placeholderWidth, height, 1)
+		if err != nil {
+			return "", fmt.Errorf("decode blurhash: %w", err)
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return "", fmt.Errorf("encode blurhash preview: %w", err)
+		}
+		styles = append(styles, "background-image: url(data:image/png;base64,"+base64.StdEncoding.EncodeToString(buf.Bytes())+")", "background-size: cover")
+	}
+
+	return strings.Join(styles, "; "), nil
+}
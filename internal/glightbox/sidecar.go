@@ -0,0 +1,96 @@
+package glightbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var sidecarClient = &http.Client{Timeout: 5 * time.Second}
+
+// SidecarEntry describes a single photo in a sidecar JSON file lying next to
+// the photos, keyed by the photo path relative to the gallery namespace:
+//
+//	{"IMG_0042.jpg": {"time": "2024-01-02T15:04:05+05:00", "alt": "...", "caption": "...",
+//	    "width": 4000, "height": 3000, "blurhash": "LEHV6nWB2yk8pyo0adR*.7kCMdnj", "color": "#8a6f4e"}}
+type SidecarEntry struct {
+	Time     string `json:"time"`
+	Alt      string `json:"alt"`
+	Caption  string `json:"caption"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Blurhash string `json:"blurhash"`
+	Color    string `json:"color"`
+}
+
+// sidecarCache keeps the parsed sidecars per URL for the given ttl, so pages
+// aren't slowed down by fetching them on every render
+type sidecarCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*cachedSidecar
+}
+
+type cachedSidecar struct {
+	fetchedAt time.Time
+	photos    map[string]SidecarEntry
+}
+
+func newSidecarCache(ttl time.Duration) *sidecarCache {
+	return &sidecarCache{
+		ttl:     ttl,
+		entries: make(map[string]*cachedSidecar),
+	}
+}
+
+// get returns the sidecar at the URL. If it can't be fetched, the previously
+// fetched one is kept, or an empty one if there is none, until the ttl passes
+// again, and the error is returned along with it
+func (c *sidecarCache) get(url string) (map[string]SidecarEntry, error) {
+	c.mu.Lock()
+	cached, found := c.entries[url]
+	c.mu.Unlock()
+	if found && time.Since(cached.fetchedAt) <= c.ttl {
+		return cached.photos, nil
+	}
+
+	photos, err := fetchSidecar(url)
+	if err != nil {
+		if found {
+			photos = cached.photos
+		} else {
+			photos = map[string]SidecarEntry{}
+		}
+	}
+
+	c.mu.Lock()
+	c.entries[url] = &cachedSidecar{fetchedAt: time.Now(), photos: photos}
+	c.mu.Unlock()
+	return photos, err
+}
+
+func fetchSidecar(url string) (map[string]SidecarEntry, error) {
+	resp, err := sidecarClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("request sidecar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request sidecar: unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read sidecar: %w", err)
+	}
+
+	entries := make(map[string]SidecarEntry)
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("unmarshal sidecar: %w", err)
+	}
+	return entries, nil
+}
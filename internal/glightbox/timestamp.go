@@ -0,0 +1,44 @@
+package glightbox
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTimestamp parses an explicit photo timestamp, the ones without an offset
+// are taken in the gallery location
+func ParseTimestamp(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		if timestamp, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return timestamp, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' does not match any of the supported layouts", value)
+}
+
+// timestampFromFileName takes the UTC date from the last two dash-separated
+// parts of the file name, e.g. "IMG-20240102-150405.jpg"
+func timestampFromFileName(url string, loc *time.Location) (time.Time, bool) {
+	name := strings.TrimSuffix(path.Base(url), path.Ext(url))
+	nameParts := strings.Split(name, "-")
+	if len(nameParts) < 2 {
+		return time.Time{}, false
+	}
+
+	timestamp, err := time.Parse("20060102 150405", nameParts[len(nameParts)-2]+" "+nameParts[len(nameParts)-1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return timestamp.In(loc), true
+}
@@ -0,0 +1,15 @@
+package htmlid
+
+import "math/rand/v2"
+
+const charset = "abcdefghijklmnopqrstuvwxyz"
+
+// New returns a random id of lowercase letters for elements rendered from
+// markdown, which only has to be unique within the page
+func New(length int) string {
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = charset[rand.IntN(len(charset))]
+	}
+	return string(b)
+}
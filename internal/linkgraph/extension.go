@@ -0,0 +1,22 @@
+package linkgraph
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/util"
+)
+
+// Extension that records internal links of a document into the parser context
+type LinkGraphExtension struct{}
+
+func NewLinkGraphExtension() goldmark.Extender {
+	return &LinkGraphExtension{}
+}
+
+func (e *LinkGraphExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithASTTransformers(
+			util.Prioritized(&LinkGraphTransformer{}, 900),
+		),
+	)
+}
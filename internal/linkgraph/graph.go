@@ -0,0 +1,68 @@
+package linkgraph
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/SayaAndy/saya-today-web/internal/blog"
+	"github.com/SayaAndy/saya-today-web/internal/corpus"
+)
+
+// Graph keeps backlinks between posts of every language, rebuilding the graph
+// of a language whenever the corpus parses its posts again
+type Graph struct {
+	corpus *corpus.Corpus
+	mu     sync.Mutex
+	langs  map[string]*langGraph
+}
+
+type langGraph struct {
+	snapshot  *corpus.Snapshot
+	backlinks map[string][]*blog.Page
+}
+
+func NewGraph(corpus *corpus.Corpus) *Graph {
+	return &Graph{
+		corpus: corpus,
+		langs:  make(map[string]*langGraph),
+	}
+}
+
+// Backlinks returns the posts linking to the codename, newest first
+func (g *Graph) Backlinks(lang string, codename string) ([]*blog.Page, error) {
+	snapshot, err := g.corpus.Get(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	graph, ok := g.langs[lang]
+	if !ok || graph.snapshot != snapshot {
+		graph = build(snapshot)
+		g.langs[lang] = graph
+	}
+
+	return graph.backlinks[codename], nil
+}
+
+func build(snapshot *corpus.Snapshot) *langGraph {
+	graph := &langGraph{snapshot: snapshot, backlinks: make(map[string][]*blog.Page)}
+	for _, post := range snapshot.Posts {
+		for _, target := range Outgoing(post.Context) {
+			if target == post.Page.FileName {
+				continue
+			}
+			graph.backlinks[target] = append(graph.backlinks[target], post.Page)
+		}
+	}
+
+	for _, sources := range graph.backlinks {
+		slices.SortFunc(sources, func(a, b *blog.Page) int {
+			return b.Metadata.PublishedTime.Compare(a.Metadata.PublishedTime)
+		})
+	}
+
+	return graph
+}
@@ -0,0 +1,67 @@
+package linkgraph
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/SayaAndy/saya-today-web/internal/mdcontext"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+var outgoingContextKey = parser.NewContextKey()
+
+type LinkGraphTransformer struct{}
+
+// Transform collects codenames of the posts the document links to, both from
+// relative "./codename" links (wiki links end up as those too) and absolute
+// "/lang/blog/codename" links in the same language
+func (t *LinkGraphTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	lang := mdcontext.Lang(pc)
+	outgoing := make([]string, 0)
+
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		link, ok := n.(*ast.Link)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+
+		codename := linkCodename(string(link.Destination), lang)
+		if codename != "" && !slices.Contains(outgoing, codename) {
+			outgoing = append(outgoing, codename)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	pc.Set(outgoingContextKey, outgoing)
+}
+
+// Outgoing returns codenames of the posts the parsed document links to
+func Outgoing(pc parser.Context) []string {
+	outgoing, _ := pc.Get(outgoingContextKey).([]string)
+	return outgoing
+}
+
+func linkCodename(destination string, lang string) string {
+	destination, _, _ = strings.Cut(destination, "#")
+	destination, _, _ = strings.Cut(destination, "?")
+
+	var codename string
+	if rest, ok := strings.CutPrefix(destination, "./"); ok {
+		codename = rest
+	} else if rest, ok := strings.CutPrefix(destination, "/"+lang+"/blog/"); ok && lang != "" {
+		codename = rest
+	} else {
+		return ""
+	}
+
+	codename = strings.TrimSuffix(strings.TrimSuffix(codename, "/"), ".md")
+	if strings.Contains(codename, "/") {
+		return ""
+	}
+	return codename
+}
@@ -0,0 +1,169 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/gofiber/fiber/v2"
+	"github.com/wneessen/go-mail"
+)
+
+// AccountIdPrefix starts the user ids of accounts, i.e. users that verified
+// an e-mail and can log in with it on any device
+const AccountIdPrefix = "acct:"
+
+const loginTokenTtl = 15 * time.Minute
+
+func IsAccount(userId string) bool {
+	return strings.HasPrefix(userId, AccountIdPrefix)
+}
+
+// Account returns the account id owning the address, creating it if the
+// address was verified before accounts existed. The e-mail and subscription
+// settings of such an address are moved to the new account
+func (m *Mailer) Account(address string) (accountId string, err error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize transaction with db: %s", err)
+	}
+
+	slog.Debug("began db transaction", slog.String("method", "Account"))
+	defer slog.Debug("ended db transaction", slog.String("method", "Account"))
+
+	err = tx.QueryRow(`SELECT account_id FROM account_table WHERE email=? LIMIT 1;`, address).Scan(&accountId)
+	if err == nil {
+		tx.Rollback()
+		return accountId, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return "", fmt.Errorf("failed to query account table in db: %s", err)
+	}
+
+	idBytes := make([]byte, 16)
+	rand.Read(idBytes)
+	accountId = AccountIdPrefix + base64.RawURLEncoding.EncodeToString(idBytes)
+
+	if _, err = tx.Exec(`INSERT INTO account_table(account_id, email) VALUES(?, ?);`, accountId, address); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("failed to create an account in db: %s", err)
+	}
+
+	var previousHash []byte
+	err = tx.QueryRow(`SELECT user_id FROM user_email_table WHERE email=? LIMIT 1;`, address).Scan(&previousHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return "", fmt.Errorf("failed to query user-email settings in db: %s", err)
+	}
+	if err == nil {
+		accountHash := m.GetHash(accountId)
+		for _, table := range []string{"user_email_table", "subscription_user_to_tags_table"} {
+			if _, err = tx.Exec(fmt.Sprintf(`UPDATE %s SET user_id=? WHERE user_id=?;`, table), accountHash, previousHash); err != nil {
+				tx.Rollback()
+				return "", fmt.Errorf("failed to move %s to the account in db: %s", table, err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("failed to commit transaction to db: %s", err)
+	}
+	return accountId, nil
+}
+
+// SendLoginLink mails a one-time login link to the address if some account
+// uses it. Nothing is sent to unknown addresses or to addresses that got a link
+// a moment ago, but no error is returned either
+func (m *Mailer) SendLoginLink(address string, lang string) error {
+	isTaken, err := m.MailIsTaken(address)
+	if err != nil {
+		return fmt.Errorf("failed to check if address is known: %w", err)
+	}
+	if !isTaken {
+		slog.Debug("login link requested for an unknown address")
+		return nil
+	}
+
+	// skipped as quietly as unknown addresses, an error here would tell the
+	// address is known
+	if err := m.holdRetry("login:"+address, loginTokenTtl); err != nil {
+		slog.Debug("login link requested again too soon", slog.String("error", err.Error()))
+		return nil
+	}
+
+	tokenBytes := make([]byte, 8)
+	rand.Read(tokenBytes)
+	token := binary.LittleEndian.Uint64(tokenBytes)
+	m.loginTokens.SetWithTTL(token, address, int64(len(address)+8), loginTokenTtl)
+	m.loginTokens.Wait()
+
+	message := mail.NewMsg()
+
+	if err := message.EnvelopeFrom(m.mailAddress); err != nil {
+		return fmt.Errorf("failed to set ENVELOPE FROM address: %w", err)
+	}
+	if err := message.FromFormat(m.publicName, m.mailAddress); err != nil {
+		return fmt.Errorf("failed to set formatted FROM address: %w", err)
+	}
+	if err := message.To(address); err != nil {
+		return fmt.Errorf("failed to set TO address: %w", err)
+	}
+
+	message.SetMessageID()
+	message.SetDate()
+	message.SetBulk()
+	message.Subject(l10n.T.GetPath(lang, "Mail", "LoginLink", "Subject").(string))
+
+	msg, err := m.tm.Render("login-link", fiber.Map{
+		"Lang":       lang,
+		"LoginToken": fmt.Sprintf("%X", token),
+		"ClientHost": m.clientHost,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render message body: %w", err)
+	}
+
+	message.SetBodyString(mail.TypeTextHTML, string(msg))
+	if err := m.outbox.enqueue("login-link", message); err != nil {
+		return fmt.Errorf("failed to queue login link message: %w", err)
+	}
+	slog.Debug("login link message queued", slog.String("address", address))
+	return nil
+}
+
+// LoginTokenAddress returns the address the login token was sent to, the
+// token stays valid until ConsumeLoginToken is called
+func (m *Mailer) LoginTokenAddress(tokenEncoded string) (address string, err error) {
+	token, err := strconv.ParseUint(tokenEncoded, 16, 64)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode login token from 8-byte hex: %w", err)
+	}
+
+	address, _ = m.loginTokens.Get(token)
+	if address == "" {
+		return "", fmt.Errorf("failed to get login info by its token (might be absent, expired or used)")
+	}
+	return address, nil
+}
+
+// ConsumeLoginToken makes the login token unusable, it is called once the
+// login it was sent for succeeds
+func (m *Mailer) ConsumeLoginToken(tokenEncoded string, address string) {
+	if token, err := strconv.ParseUint(tokenEncoded, 16, 64); err == nil {
+		m.loginTokens.Del(token)
+	}
+
+	m.lostMailMapMutex.Lock()
+	delete(m.lostMailMap, "login:"+address)
+	m.lostMailMapMutex.Unlock()
+}
@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/SayaAndy/saya-today-web/internal/comments"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/gofiber/fiber/v2"
+	"github.com/wneessen/go-mail"
+)
+
+// IsAuthor reports whether the user verified the address of the blog author,
+// which makes the user a moderator of comments
+func (m *Mailer) IsAuthor(userId string) (bool, error) {
+	email, _, err := m.GetInfo(m.GetHash(userId))
+	if err != nil {
+		return false, err
+	}
+	return email != "" && strings.EqualFold(email, m.authorAddress), nil
+}
+
+// NotifyComment tells the blog author a new comment waits for moderation
+func (m *Mailer) NotifyComment(comment *comments.Comment, postTitle string) error {
+	message := mail.NewMsg()
+
+	if err := message.EnvelopeFrom(m.mailAddress); err != nil {
+		return fmt.Errorf("failed to set ENVELOPE FROM address: %w", err)
+	}
+	if err := message.FromFormat(m.publicName, m.mailAddress); err != nil {
+		return fmt.Errorf("failed to set formatted FROM address: %w", err)
+	}
+	if err := message.To(m.authorAddress); err != nil {
+		return fmt.Errorf("failed to set TO address: %w", err)
+	}
+	if err := message.ReplyTo(comment.Email); err != nil {
+		slog.Warn("failed to set REPLY-TO address", slog.String("error", err.Error()))
+	}
+
+	message.SetMessageID()
+	message.SetDate()
+	message.Subject(strings.ReplaceAll(l10n.T.GetPath(comment.Lang, "Mail", "NewComment", "Subject").(string), "{}", postTitle))
+
+	msg, err := m.tm.Render("new-comment", fiber.Map{
+		"Lang":       comment.Lang,
+		"Comment":    comment,
+		"PostTitle":  postTitle,
+		"ClientHost": m.clientHost,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render message body: %w", err)
+	}
+
+	message.SetBodyString(mail.TypeTextHTML, string(msg))
+	if err := m.outbox.enqueue("new-comment", message); err != nil {
+		return fmt.Errorf("failed to queue new comment message: %w", err)
+	}
+	slog.Debug("new comment message queued", slog.Int64("comment_id", comment.Id))
+	return nil
+}
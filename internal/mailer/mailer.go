@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"html/template"
 	"log/slog"
@@ -14,7 +15,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/SayaAndy/saya-today-web/config"
 	"github.com/SayaAndy/saya-today-web/internal/blog"
+	"github.com/SayaAndy/saya-today-web/internal/lru"
 	"github.com/SayaAndy/saya-today-web/internal/templatemanager"
 	"github.com/SayaAndy/saya-today-web/l10n"
 	"github.com/dgraph-io/ristretto/v2"
@@ -25,14 +28,18 @@ import (
 
 type Mailer struct {
 	verificationCodes *ristretto.Cache[uint64, string]
-	unsubscribeCodes  *ristretto.Cache[uint64, []byte]
+	loginTokens       *ristretto.Cache[uint64, string]
 	db                *sql.DB
 	tm                *templatemanager.TemplateManager
-	mailClient        *mail.Client
+	outbox            *outbox
 	clientHost        string
 	mailAddress       string
+	authorAddress     string
 	publicName        string
 	salt              []byte
+	previousSalts     [][]byte
+	unsubscribeSecret []byte
+	photoStorage      config.PhotoStorageConfig
 
 	lostMailMap map[string]struct {
 		Dur        time.Duration
@@ -41,10 +48,15 @@ type Mailer struct {
 	}
 	lostMailMapMutex sync.RWMutex
 
-	hashMap      map[string][]byte
-	hashMapMutex sync.RWMutex
+	hashes    *lru.Cache[string, []byte]
+	hashMutex sync.Mutex
+
+	// ids already checked for settings left under previous salts
+	rehashed *lru.Cache[string, struct{}]
 }
 
+const verificationCodeTtl = time.Hour
+
 type SubscriptionType int
 
 const (
@@ -53,7 +65,7 @@ const (
 	Specific
 )
 
-func NewMailer(db *sql.DB, clientHost string, mailHost string, publicName string, mailAddress string, username string, password string, salt []byte) (*Mailer, error) {
+func NewMailer(db *sql.DB, clientHost string, publicName string, mailAddress string, authorAddress string, salt []byte, previousSalts [][]byte, unsubscribeSecret []byte, photoStorage config.PhotoStorageConfig, transport Transport, sendPerMinute int) (*Mailer, error) {
 	verificationCodes, err := ristretto.NewCache(&ristretto.Config[uint64, string]{
 		NumCounters:            10000,
 		MaxCost:                1 << 20, // 1 MB
@@ -64,14 +76,14 @@ func NewMailer(db *sql.DB, clientHost string, mailHost string, publicName string
 		return nil, fmt.Errorf("fail to initialize cache for verification codes: %w", err)
 	}
 
-	unsubscribeCodes, err := ristretto.NewCache(&ristretto.Config[uint64, []byte]{
+	loginTokens, err := ristretto.NewCache(&ristretto.Config[uint64, string]{
 		NumCounters:            10000,
 		MaxCost:                1 << 20, // 1 MB
 		BufferItems:            64,
-		TtlTickerDurationInSec: 86400, // 1 day
+		TtlTickerDurationInSec: 60,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("fail to initialize cache for verification codes: %w", err)
+		return nil, fmt.Errorf("fail to initialize cache for login tokens: %w", err)
 	}
 
 	tm, err := templatemanager.NewTemplateManager(templatemanager.TemplateManagerTemplates{
@@ -80,30 +92,35 @@ func NewMailer(db *sql.DB, clientHost string, mailHost string, publicName string
 	}, templatemanager.TemplateManagerTemplates{
 		Name:  "verify-email",
 		Files: []string{"views/layouts/general-mail.html", "views/messages/verify-email.html"},
+	}, templatemanager.TemplateManagerTemplates{
+		Name:  "login-link",
+		Files: []string{"views/layouts/general-mail.html", "views/messages/login-link.html"},
+	}, templatemanager.TemplateManagerTemplates{
+		Name:  "new-comment",
+		Files: []string{"views/layouts/general-mail.html", "views/messages/new-comment.html"},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("fail to initialize template manager for message templating: %w", err)
 	}
 
-	mailClient, err := mail.NewClient(mailHost,
-		mail.WithSMTPAuth(mail.SMTPAuthAutoDiscover), mail.WithTLSPortPolicy(mail.TLSMandatory),
-		mail.WithUsername(username), mail.WithPassword(password),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("fail to initialize mail client: %w", err)
-	}
+	outbox := newOutbox(db, transport, mailAddress, sendPerMinute)
 
 	return &Mailer{
 		verificationCodes: verificationCodes,
-		unsubscribeCodes:  unsubscribeCodes,
+		loginTokens:       loginTokens,
 		db:                db,
 		clientHost:        clientHost,
 		tm:                tm,
-		mailClient:        mailClient,
+		outbox:            outbox,
 		mailAddress:       mailAddress,
+		authorAddress:     authorAddress,
 		publicName:        publicName,
 		salt:              salt,
-		hashMap:           make(map[string][]byte),
+		previousSalts:     previousSalts,
+		unsubscribeSecret: unsubscribeSecret,
+		photoStorage:      photoStorage,
+		hashes:            lru.New[string, []byte](lru.ClientCapacity),
+		rehashed:          lru.New[string, struct{}](lru.ClientCapacity),
 		lostMailMap: make(map[string]struct {
 			Dur        time.Duration
 			End        time.Time
@@ -113,25 +130,43 @@ func NewMailer(db *sql.DB, clientHost string, mailHost string, publicName string
 }
 
 func (m *Mailer) GetHash(id string) []byte {
-	m.hashMapMutex.RLock()
-	if val, ok := m.hashMap[id]; ok {
-		m.hashMapMutex.RUnlock()
+	if val, ok := m.hashes.Get(id); ok {
 		slog.Debug("gave an old hash", slog.String("hash", base64.RawStdEncoding.EncodeToString(val)))
 		return val
 	}
-	m.hashMapMutex.RUnlock()
 
-	m.hashMapMutex.Lock()
-	defer m.hashMapMutex.Unlock()
+	m.hashMutex.Lock()
+	defer m.hashMutex.Unlock()
 
-	if val, ok := m.hashMap[id]; ok {
+	if val, ok := m.hashes.Get(id); ok {
 		slog.Debug("gave a newly generated hash", slog.String("hash", base64.RawStdEncoding.EncodeToString(val)))
 		return val
 	}
 
-	m.hashMap[id] = argon2.IDKey([]byte(id), m.salt, 1, 64*1024, 4, 32)
-	slog.Debug("generated hash", slog.String("hash", base64.RawStdEncoding.EncodeToString(m.hashMap[id])))
-	return m.hashMap[id]
+	val := argon2.IDKey([]byte(id), m.salt, 1, 64*1024, 4, 32)
+	m.hashes.Add(id, val)
+	slog.Debug("generated hash", slog.String("hash", base64.RawStdEncoding.EncodeToString(val)))
+	return val
+}
+
+// holdRetry forbids sending another message for the key for a minute, doubling
+// the wait with every retry
+func (m *Mailer) holdRetry(key string, codeTtl time.Duration) error {
+	dur := 1 * time.Minute
+	m.lostMailMapMutex.Lock()
+	defer m.lostMailMapMutex.Unlock()
+	if previous, ok := m.lostMailMap[key]; ok {
+		if time.Now().Before(previous.End) {
+			return fmt.Errorf("retry is not allowed until %s", previous.End)
+		}
+		dur = 2 * previous.Dur
+	}
+	m.lostMailMap[key] = struct {
+		Dur        time.Duration
+		End        time.Time
+		CodeExpiry time.Time
+	}{Dur: dur, End: time.Now().Add(dur), CodeExpiry: time.Now().Add(codeTtl)}
+	return nil
 }
 
 func (m *Mailer) IsAllowedToRetryVerification(userId string) (retryAllowed bool, whenAllowed time.Time, codeExpiry time.Time) {
@@ -201,24 +236,6 @@ func (m *Mailer) GetInfo(userIdHash []byte) (email string, lang string, err erro
 	return
 }
 
-func (m *Mailer) Unsubscribe(unsubscribeCodeString string) (clientError error, serverError error) {
-	unsubscribeCode, err := strconv.ParseUint(unsubscribeCodeString, 16, 64)
-	if err != nil {
-		return fmt.Errorf("invalid unsubscribe code: %s", err), nil
-	}
-
-	userId, _ := m.unsubscribeCodes.Get(unsubscribeCode)
-	if len(userId) == 0 {
-		return fmt.Errorf("invalid unsubscribe code: have no information about it"), nil
-	}
-
-	if err = m.Subscribe(userId, None); err != nil {
-		return nil, fmt.Errorf("failed to unsubscribe: %s", err)
-	}
-
-	return nil, nil
-}
-
 func (m *Mailer) SendVerificationCode(userId string, address string, lang string) error {
 	message := mail.NewMsg()
 
@@ -236,28 +253,16 @@ func (m *Mailer) SendVerificationCode(userId string, address string, lang string
 	message.SetDate()
 	message.SetBulk()
 
-	dur := 1 * time.Minute
-	m.lostMailMapMutex.Lock()
-	if previous, ok := m.lostMailMap[userId]; ok {
-		if time.Now().Before(previous.End) {
-			m.lostMailMapMutex.Unlock()
-			return fmt.Errorf("user is not allowed to send another verification code until %s", previous.End)
-		}
-		dur = 2 * m.lostMailMap[userId].Dur
+	if err := m.holdRetry(userId, verificationCodeTtl); err != nil {
+		return fmt.Errorf("user is not allowed to send another verification code: %w", err)
 	}
-	m.lostMailMap[userId] = struct {
-		Dur        time.Duration
-		End        time.Time
-		CodeExpiry time.Time
-	}{Dur: dur, End: time.Now().Add(dur), CodeExpiry: time.Now().Add(time.Hour)}
-	m.lostMailMapMutex.Unlock()
 
 	verificationCodeBytes := make([]byte, 8)
 	rand.Read(verificationCodeBytes)
 	verificationCode := binary.LittleEndian.Uint64(verificationCodeBytes)
 
 	verificationInfo := fmt.Sprintf("%s.%s", base64.RawStdEncoding.EncodeToString([]byte(userId)), base64.RawStdEncoding.EncodeToString([]byte(address)))
-	m.verificationCodes.Set(verificationCode, verificationInfo, int64(len(verificationInfo)+8))
+	m.verificationCodes.SetWithTTL(verificationCode, verificationInfo, int64(len(verificationInfo)+8), verificationCodeTtl)
 
 	message.Subject(l10n.T.GetPath(lang, "Mail", "VerifyEmail", "Subject").(string))
 
@@ -271,69 +276,82 @@ func (m *Mailer) SendVerificationCode(userId string, address string, lang string
 	}
 
 	message.SetBodyString(mail.TypeTextHTML, string(msg))
-	if err := m.mailClient.DialAndSend(message); err != nil {
-		return fmt.Errorf("failed to send verification code message: %w", err)
+	if err := m.outbox.enqueue("verify-email", message); err != nil {
+		return fmt.Errorf("failed to queue verification code message: %w", err)
 	}
-	slog.Debug("verification code message successfully delivered", slog.String("address", address), slog.String("user_id", userId))
+	slog.Debug("verification code message queued", slog.String("address", address), slog.String("user_id", userId))
 	return nil
 }
 
-func (m *Mailer) Verify(verificationCodeEncoded string, lang string) error {
+// Verify binds the address to the user who requested the verification code
+// and returns both of them
+func (m *Mailer) Verify(verificationCodeEncoded string, lang string) (userIdString string, addressString string, err error) {
 	verificationCode, err := strconv.ParseUint(verificationCodeEncoded, 16, 64)
 	if err != nil {
-		return fmt.Errorf("failed to decode verification code from 8-byte hex: %w", err)
+		return "", "", fmt.Errorf("failed to decode verification code from 8-byte hex: %w", err)
 	}
 
 	verificationInfo, _ := m.verificationCodes.Get(verificationCode)
 	if verificationInfo == "" {
-		return fmt.Errorf("failed to get verification info by its code (might be absent, might be empty)")
+		return "", "", fmt.Errorf("failed to get verification info by its code (might be absent, might be empty)")
 	}
 
 	verificationSegments := strings.Split(verificationInfo, ".")
 	if len(verificationSegments) != 2 {
 		m.verificationCodes.Del(verificationCode)
-		return fmt.Errorf("invalid format of verification info: expected %d segments, got %d", 2, len(verificationSegments))
+		return "", "", fmt.Errorf("invalid format of verification info: expected %d segments, got %d", 2, len(verificationSegments))
 	}
 
 	userId, err := base64.RawStdEncoding.DecodeString(verificationSegments[0])
 	if err != nil {
 		m.verificationCodes.Del(verificationCode)
 		delete(m.lostMailMap, verificationSegments[0])
-		return fmt.Errorf("could not decode user id: %s", err)
+		return "", "", fmt.Errorf("could not decode user id: %s", err)
 	}
 
 	address, err := base64.RawStdEncoding.DecodeString(verificationSegments[1])
 	if err != nil {
 		m.verificationCodes.Del(verificationCode)
 		delete(m.lostMailMap, verificationSegments[0])
-		return fmt.Errorf("could not decode address: %s", err)
+		return "", "", fmt.Errorf("could not decode address: %s", err)
 	}
 
 	tx, err := m.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to initialize transaction with db: %s", err)
+		return "", "", fmt.Errorf("failed to initialize transaction with db: %s", err)
 	}
 
 	slog.Debug("began db transaction", slog.String("method", "Verify"))
-	if _, err = tx.Exec(`INSERT INTO user_email_table(user_id, email, lang) VALUES(?, ?, ?)
+	// links mailed to a previous address of the user stop working once it changes
+	unsubscribeIdBytes := make([]byte, 16)
+	rand.Read(unsubscribeIdBytes)
+	if _, err = tx.Exec(`INSERT INTO user_email_table(user_id, email, lang, unsubscribe_id) VALUES(?, ?, ?, ?)
   ON CONFLICT(user_id) DO UPDATE SET
   	email=excluded.email,
-	lang=excluded.lang;`, m.GetHash(string(userId)), address, lang); err != nil {
+	lang=excluded.lang,
+	unsubscribe_id=CASE WHEN email=excluded.email THEN unsubscribe_id ELSE excluded.unsubscribe_id END;`,
+		m.GetHash(string(userId)), address, lang, hex.EncodeToString(unsubscribeIdBytes)); err != nil {
+		tx.Rollback()
+		slog.Debug("ended db transaction", slog.String("method", "Verify"))
+		return "", "", fmt.Errorf("failed to configure user-email settings in db: %s", err)
+	}
+
+	if _, err = tx.Exec(`UPDATE account_table SET email=? WHERE account_id=?;`, address, string(userId)); err != nil {
 		tx.Rollback()
 		slog.Debug("ended db transaction", slog.String("method", "Verify"))
-		return fmt.Errorf("failed to configure user-email settings in db: %s", err)
+		return "", "", fmt.Errorf("failed to update account e-mail in db: %s", err)
 	}
 
 	if err = tx.Commit(); err != nil {
 		tx.Rollback()
 		slog.Debug("ended db transaction", slog.String("method", "Verify"))
-		return fmt.Errorf("failed to commit transaction to db: %s", err)
+		return "", "", fmt.Errorf("failed to commit transaction to db: %s", err)
 	}
 	slog.Debug("ended db transaction", slog.String("method", "Verify"))
 
 	m.verificationCodes.Del(verificationCode)
 	delete(m.lostMailMap, verificationSegments[0])
-	return nil
+	return string(userId), string(address), nil
 }
 
 func (m *Mailer) GetSubscriptions(userId string) (subscriptionType SubscriptionType, tags []string, err error) {
@@ -415,6 +433,60 @@ func (m *Mailer) Subscribe(userIdHash []byte, subscriptionType SubscriptionType,
 	return nil
 }
 
+// Migrate moves the e-mail and subscription settings of one client to another,
+// unless the target already has its own
+func (m *Mailer) Migrate(fromId string, toId string) error {
+	return m.migrateHash(m.GetHash(fromId), m.GetHash(toId))
+}
+
+// Rehash moves the e-mail and subscription settings the user left under
+// previous salts to its current hash. Every id is checked once while it
+// stays in memory
+func (m *Mailer) Rehash(id string) error {
+	if len(m.previousSalts) == 0 {
+		return nil
+	}
+	if _, ok := m.rehashed.Get(id); ok {
+		return nil
+	}
+
+	hash := m.GetHash(id)
+	for _, salt := range m.previousSalts {
+		if err := m.migrateHash(argon2.IDKey([]byte(id), salt, 1, 64*1024, 4, 32), hash); err != nil {
+			return err
+		}
+	}
+	m.rehashed.Add(id, struct{}{})
+	return nil
+}
+
+func (m *Mailer) migrateHash(fromHash []byte, toHash []byte) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to initialize transaction with db: %s", err)
+	}
+
+	slog.Debug("began db transaction", slog.String("method", "Migrate"))
+
+	for _, table := range []string{"user_email_table", "subscription_user_to_tags_table"} {
+		if _, err = tx.Exec(fmt.Sprintf(`UPDATE %s SET user_id=?
+  WHERE user_id=? AND NOT EXISTS (SELECT 1 FROM %s WHERE user_id=?);`, table, table), toHash, fromHash, toHash); err != nil {
+			tx.Rollback()
+			slog.Debug("ended db transaction", slog.String("method", "Migrate"))
+			return fmt.Errorf("failed to migrate %s in db for the user: %s", table, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		tx.Rollback()
+		slog.Debug("ended db transaction", slog.String("method", "Migrate"))
+		return fmt.Errorf("failed to commit transaction to db: %s", err)
+	}
+	slog.Debug("ended db transaction", slog.String("method", "Migrate"))
+
+	return nil
+}
+
 func (m *Mailer) NewPost(post *blog.Page) error {
 	tx, err := m.db.Begin()
 	if err != nil {
@@ -492,17 +564,21 @@ rowLoop:
 		if user.email == "" {
 			continue
 		}
-		unsubscribeCodeBytes := make([]byte, 8)
-		rand.Read(unsubscribeCodeBytes)
-		unsubscribeCode := binary.LittleEndian.Uint64(unsubscribeCodeBytes)
+		unsubscribeId, err := m.getUnsubscribeId(user.userId)
+		if err != nil {
+			slog.Warn("failed to get unsubscribe id of the user", slog.String("error", err.Error()), slog.String("user_id", base64.RawStdEncoding.EncodeToString(user.userId)))
+			continue
+		}
+		unsubscribeLink := fmt.Sprintf("https://%s/%s/user/unsubscribe?code=%s", m.clientHost, post.Lang, m.unsubscribeToken(unsubscribeId, unsubscribeScopeAll))
 
-		unsubscribeFooter := strings.Replace(l10n.T.GetPath(post.Lang, "Mail", "UnsubscribeFooter").(string), "{}", fmt.Sprintf(`<a style="color: #273de1 !important;" href="https://%s/%s/user/unsubscribe?code=%X">`, m.clientHost, post.Lang, unsubscribeCode), 1)
+		unsubscribeFooter := strings.Replace(l10n.T.GetPath(post.Lang, "Mail", "UnsubscribeFooter").(string), "{}", fmt.Sprintf(`<a style="color: #273de1 !important;" href="%s">`, unsubscribeLink), 1)
 		unsubscribeFooter = strings.Replace(unsubscribeFooter, "{/}", "</a>", 1)
 
 		msgBody, err := m.tm.Render("new-post", fiber.Map{
 			"Lang":              post.Lang,
 			"Post":              post,
 			"ClientHost":        m.clientHost,
+			"PhotoStorage":      m.photoStorage,
 			"UnsubscribeFooter": template.HTML(unsubscribeFooter),
 		})
 
@@ -525,16 +601,16 @@ rowLoop:
 		message.SetMessageID()
 		message.SetDate()
 		message.SetBulk()
+		message.SetGenHeader(mail.HeaderListUnsubscribe, "<"+unsubscribeLink+">")
+		message.SetGenHeader(mail.HeaderListUnsubscribePost, "List-Unsubscribe=One-Click")
 		message.Subject(l10n.T.GetPath(post.Lang, "Mail", "NewPost", "Subject").(string))
 		message.SetBodyString(mail.TypeTextHTML, string(msgBody))
 
-		m.unsubscribeCodes.Set(unsubscribeCode, user.userId, 40)
-
 		messages = append(messages, message)
 	}
 
-	if err := m.mailClient.DialAndSend(messages...); err != nil {
-		return fmt.Errorf("failed to send new post notifications: %w", err)
+	if err := m.outbox.enqueue("new-post", messages...); err != nil {
+		return fmt.Errorf("failed to queue new post notifications: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,173 @@
+package mailer
+
+import (
+	"database/sql"
+	"mime"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/config"
+	"github.com/SayaAndy/saya-today-web/internal/blog"
+	"github.com/SayaAndy/saya-today-web/internal/frontmatter"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var testMigrations = []string{
+	"2_create_subscription_tables.up.sql",
+	"10_create_mail_outbox_table.up.sql",
+	"11_add_unsubscribe_id_to_user_email_table.up.sql",
+}
+
+// message templates are read relative to the repository root
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+t.TempDir()+"/mail.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	for _, name := range testMigrations {
+		migration, err := os.ReadFile("migrations/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = db.Exec(string(migration)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+// subscribe stores a verified address subscribed to every post
+func subscribe(t *testing.T, m *Mailer, userId string, address string, lang string) {
+	t.Helper()
+	hash := m.GetHash(userId)
+	if _, err := m.db.Exec(`INSERT INTO user_email_table(user_id, email, lang, unsubscribe_id) VALUES(?, ?, ?, ?);`,
+		hash, address, lang, userId+"-unsubscribe"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Subscribe(hash, All); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForMessage returns the first message the transport wrote into dir
+func waitForMessage(t *testing.T, dir string) *mail.Message {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) > 0 {
+			file, err := os.Open(files[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { file.Close() })
+
+			message, err := mail.ReadMessage(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return message
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no message was written in time")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestNewPostWritesMessage(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+
+	transport, err := NewTransport(config.MailTransportConfig{Type: "file", Config: &config.MailFileConfig{Dir: dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	photoStorage := config.PhotoStorageConfig{
+		Full:          config.PhotoTypeConfig{BaseUrl: "https://photos.saya.uz/full/%s"},
+		DefaultFormat: "jpg",
+	}
+	m, err := NewMailer(db, "saya.uz", "SAYA.UZ", "noreply@saya.uz", "author@saya.uz",
+		[]byte("salt"), nil, []byte("secret"), photoStorage, transport, 6000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	subscribe(t, m, "reader", "reader@example.com", "en")
+	subscribe(t, m, "chitatel", "chitatel@example.com", "ru")
+
+	if err = m.NewPost(&blog.Page{
+		FileName: "first-post",
+		Lang:     "en",
+		Metadata: &frontmatter.Metadata{Title: "First post", ActionDate: "2024-01-02", Thumbnail: "cats.jpg", Tags: []string{"cats"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var queued int
+	if err = db.QueryRow(`SELECT COUNT(*) FROM mail_outbox_table;`).Scan(&queued); err != nil {
+		t.Fatal(err)
+	}
+	if queued != 1 {
+		t.Fatalf("expected the post to be mailed to the en subscriber only, got %d messages", queued)
+	}
+
+	message := waitForMessage(t, dir)
+
+	to, err := message.Header.AddressList("To")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(to) != 1 || to[0].Address != "reader@example.com" {
+		t.Fatalf("expected the message to reader@example.com, got %v", to)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := l10n.T.GetPath("en", "Mail", "NewPost", "Subject").(string); subject != want {
+		t.Fatalf("expected subject '%s', got '%s'", want, subject)
+	}
+
+	listUnsubscribe := message.Header.Get("List-Unsubscribe")
+	link, ok := strings.CutPrefix(listUnsubscribe, "<https://saya.uz/en/user/unsubscribe?code=")
+	if !ok || !strings.HasSuffix(link, ">") {
+		t.Fatalf("expected a link to the unsubscribe page in List-Unsubscribe, got '%s'", listUnsubscribe)
+	}
+	code, err := url.QueryUnescape(strings.TrimSuffix(link, ">"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.VerifyUnsubscribeToken(code); err != nil {
+		t.Fatalf("expected a valid unsubscribe token, got %s", err)
+	}
+	if strings.Contains(listUnsubscribe, "reader@example.com") {
+		t.Fatalf("expected the address to stay out of List-Unsubscribe, got '%s'", listUnsubscribe)
+	}
+
+	if post := message.Header.Get("List-Unsubscribe-Post"); post != "List-Unsubscribe=One-Click" {
+		t.Fatalf("expected one-click List-Unsubscribe-Post, got '%s'", post)
+	}
+}
@@ -0,0 +1,233 @@
+package mailer
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	netmail "net/mail"
+	"strings"
+	"time"
+
+	"github.com/wneessen/go-mail"
+)
+
+const (
+	outboxPending = "pending"
+	outboxSent    = "sent"
+	outboxDead    = "dead"
+)
+
+const (
+	// outboxMaxAttempts is how many times a message is tried before it is left
+	// dead in the outbox
+	outboxMaxAttempts  = 8
+	outboxBaseBackoff  = time.Minute
+	outboxMaxBackoff   = 6 * time.Hour
+	outboxPollInterval = 30 * time.Second
+	// sent messages are kept for a while to tell what was delivered and when
+	outboxSentRetention = 30 * 24 * time.Hour
+	outboxPruneInterval = time.Hour
+)
+
+// outboxCodeTtls lists the kinds of messages carrying a code. They go ahead of
+// bulk mail like new posts, and are given up once the code has expired
+var outboxCodeTtls = map[string]time.Duration{
+	"verify-email": verificationCodeTtl,
+	"login-link":   loginTokenTtl,
+}
+
+// outbox keeps outgoing messages in the database until they are delivered, so
+// neither a failing SMTP server nor a restart loses them. A single worker
+// sends them one by one no faster than the configured rate
+type outbox struct {
+	db           *sql.DB
+	transport    Transport
+	envelopeFrom string
+	interval     time.Duration
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newOutbox(db *sql.DB, transport Transport, envelopeFrom string, sendPerMinute int) *outbox {
+	o := &outbox{
+		db:           db,
+		transport:    transport,
+		envelopeFrom: envelopeFrom,
+		interval:     time.Minute / time.Duration(max(sendPerMinute, 1)),
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go o.run()
+	return o
+}
+
+// enqueue stores the messages to be sent by the worker, either all of them
+// are stored or none
+func (o *outbox) enqueue(kind string, messages ...*mail.Msg) error {
+	tx, err := o.db.Begin()
+	if err != nil {
+		return fmt.Errorf("fail to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	priority, expiresAt := 0, sql.NullInt64{}
+	if ttl, ok := outboxCodeTtls[kind]; ok {
+		priority, expiresAt = 1, sql.NullInt64{Int64: now.Add(ttl).Unix(), Valid: true}
+	}
+
+	for _, message := range messages {
+		recipients, err := message.GetRecipients()
+		if err != nil {
+			return fmt.Errorf("fail to get recipients of the message: %w", err)
+		}
+		for i := range recipients {
+			address, err := netmail.ParseAddress(recipients[i])
+			if err != nil {
+				return fmt.Errorf("fail to parse recipient '%s': %w", recipients[i], err)
+			}
+			recipients[i] = address.Address
+		}
+
+		var eml bytes.Buffer
+		if _, err = message.WriteTo(&eml); err != nil {
+			return fmt.Errorf("fail to serialize the message: %w", err)
+		}
+
+		if _, err = tx.Exec(`INSERT INTO mail_outbox_table (kind, recipient, message, priority, next_attempt_at, created_at, expires_at)
+  VALUES (?, ?, ?, ?, ?, ?, ?);`, kind, strings.Join(recipients, ","), eml.Bytes(), priority, now.Unix(), now.Unix(), expiresAt); err != nil {
+			return fmt.Errorf("fail to insert the message into outbox: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("fail to commit transaction: %w", err)
+	}
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close stops sending queued messages, they stay in the outbox until the next
+// start
+func (m *Mailer) Close() {
+	m.outbox.close()
+}
+
+// close stops the worker, messages left unsent are picked up after a restart
+func (o *outbox) close() {
+	close(o.stop)
+	<-o.done
+}
+
+func (o *outbox) run() {
+	defer close(o.done)
+
+	var lastSent, lastPruned time.Time
+	for {
+		if time.Since(lastPruned) > outboxPruneInterval {
+			o.prune()
+			lastPruned = time.Now()
+		}
+
+		id, recipient, eml, attempts, err := o.next()
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				slog.Error("failed to get next message from outbox", slog.String("error", err.Error()))
+			}
+			select {
+			case <-o.stop:
+				return
+			case <-o.wake:
+			case <-time.After(outboxPollInterval):
+			}
+			continue
+		}
+
+		if wait := o.interval - time.Since(lastSent); wait > 0 {
+			select {
+			case <-o.stop:
+				return
+			case <-time.After(wait):
+			}
+		}
+		lastSent = time.Now()
+		o.deliver(id, strings.Split(recipient, ","), eml, attempts)
+	}
+}
+
+// next returns the oldest pending message due to be sent, messages of higher
+// priority first. Messages whose code has expired are left dead on the way
+func (o *outbox) next() (id int64, recipient string, eml []byte, attempts int, err error) {
+	now := time.Now().Unix()
+	result, err := o.db.Exec(`UPDATE mail_outbox_table SET status=?, last_error='expired before delivery'
+  WHERE status=? AND expires_at<=?;`, outboxDead, outboxPending, now)
+	if err != nil {
+		return 0, "", nil, 0, fmt.Errorf("fail to drop expired messages: %w", err)
+	}
+	if expired, _ := result.RowsAffected(); expired > 0 {
+		slog.Warn("messages expired before delivery", slog.Int64("count", expired))
+	}
+
+	err = o.db.QueryRow(`SELECT message_id, recipient, message, attempts FROM mail_outbox_table
+  WHERE status=? AND next_attempt_at<=? ORDER BY priority DESC, next_attempt_at, message_id LIMIT 1;`,
+		outboxPending, now).Scan(&id, &recipient, &eml, &attempts)
+	return id, recipient, eml, attempts, err
+}
+
+// deliver sends the message and records the outcome, failed messages are
+// retried with exponential backoff unless the server rejected them for good
+func (o *outbox) deliver(id int64, to []string, eml []byte, attempts int) {
+	err := o.transport.Send(o.envelopeFrom, to, eml)
+	if err == nil {
+		if _, err = o.db.Exec(`UPDATE mail_outbox_table SET status=?, attempts=?, last_error='', sent_at=? WHERE message_id=?;`,
+			outboxSent, attempts+1, time.Now().Unix(), id); err != nil {
+			slog.Error("failed to mark message as sent", slog.Int64("message_id", id), slog.String("error", err.Error()))
+		}
+		slog.Debug("message from outbox successfully delivered", slog.Int64("message_id", id))
+		return
+	}
+
+	attempts++
+	if attempts >= outboxMaxAttempts || errors.Is(err, ErrRejected) {
+		slog.Error("failed to deliver message, giving up", slog.Int64("message_id", id), slog.Int("attempts", attempts), slog.String("error", err.Error()))
+		o.markDead(id, attempts, err)
+		return
+	}
+
+	backoff := min(outboxBaseBackoff*time.Duration(math.Pow(2, float64(attempts-1))), outboxMaxBackoff)
+	slog.Warn("failed to deliver message, will retry", slog.Int64("message_id", id), slog.Int("attempts", attempts),
+		slog.Duration("backoff", backoff), slog.String("error", err.Error()))
+	if _, dbErr := o.db.Exec(`UPDATE mail_outbox_table SET attempts=?, next_attempt_at=?, last_error=? WHERE message_id=?;`,
+		attempts, time.Now().Add(backoff).Unix(), err.Error(), id); dbErr != nil {
+		slog.Error("failed to reschedule message", slog.Int64("message_id", id), slog.String("error", dbErr.Error()))
+	}
+}
+
+func (o *outbox) markDead(id int64, attempts int, cause error) {
+	if _, err := o.db.Exec(`UPDATE mail_outbox_table SET status=?, attempts=?, last_error=? WHERE message_id=?;`,
+		outboxDead, attempts, cause.Error(), id); err != nil {
+		slog.Error("failed to mark message as dead", slog.Int64("message_id", id), slog.String("error", err.Error()))
+	}
+}
+
+func (o *outbox) prune() {
+	result, err := o.db.Exec(`DELETE FROM mail_outbox_table WHERE status=? AND sent_at<?;`,
+		outboxSent, time.Now().Add(-outboxSentRetention).Unix())
+	if err != nil {
+		slog.Error("failed to prune sent messages from outbox", slog.String("error", err.Error()))
+		return
+	}
+	if pruned, _ := result.RowsAffected(); pruned > 0 {
+		slog.Debug("pruned sent messages from outbox", slog.Int64("count", pruned))
+	}
+}
@@ -0,0 +1,177 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/textproto"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/config"
+	"github.com/wneessen/go-mail"
+)
+
+// ErrRejected marks a message refused for good, sending it again won't help
+var ErrRejected = errors.New("message is rejected")
+
+// Transport delivers a message serialized as EML from the envelope sender to
+// the recipients. Messages are passed as they were queued, since parsing them
+// back into mail.Msg loses some of the headers
+type Transport interface {
+	Send(from string, to []string, eml []byte) error
+}
+
+func NewTransport(cfg config.MailTransportConfig) (Transport, error) {
+	switch cfg.Type {
+	case "smtp":
+		smtpConfig := cfg.Config.(*config.SmtpConfig)
+		client, err := mail.NewClient(smtpConfig.Host,
+			mail.WithSMTPAuth(mail.SMTPAuthAutoDiscover), mail.WithTLSPortPolicy(mail.TLSMandatory),
+			mail.WithUsername(smtpConfig.Username), mail.WithPassword(smtpConfig.Password),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("fail to initialize mail client: %w", err)
+		}
+		return &SmtpTransport{client: client}, nil
+	case "file":
+		dir := cfg.Config.(*config.MailFileConfig).Dir
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("fail to create directory for messages: %w", err)
+		}
+		return &FileTransport{dir: dir}, nil
+	case "log":
+		return &LogTransport{}, nil
+	case "sendmail":
+		return &SendmailTransport{path: cfg.Config.(*config.SendmailConfig).Path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported mail transport type: %s", cfg.Type)
+	}
+}
+
+// SmtpTransport sends messages through an SMTP server, one connection each
+type SmtpTransport struct {
+	client *mail.Client
+}
+
+func (t *SmtpTransport) Send(from string, to []string, eml []byte) error {
+	smtpClient, err := t.client.DialToSMTPClientWithContext(context.Background())
+	if err != nil {
+		return fmt.Errorf("fail to dial SMTP server: %w", err)
+	}
+	defer func() {
+		// the message is already accepted or refused at this point
+		if err := t.client.CloseWithSMTPClient(smtpClient); err != nil {
+			slog.Warn("failed to close SMTP connection", slog.String("error", err.Error()))
+		}
+	}()
+
+	if err = smtpClient.Mail("<" + from + ">"); err != nil {
+		return smtpRejection(fmt.Errorf("fail to send MAIL FROM: %w", err))
+	}
+	for _, rcpt := range to {
+		if err = smtpClient.Rcpt("<" + rcpt + ">"); err != nil {
+			return smtpRejection(fmt.Errorf("fail to send RCPT TO: %w", err))
+		}
+	}
+
+	writer, err := smtpClient.Data()
+	if err != nil {
+		return fmt.Errorf("fail to send DATA: %w", err)
+	}
+	if _, err = writer.Write(eml); err != nil {
+		return fmt.Errorf("fail to write message: %w", err)
+	}
+	if err = writer.Close(); err != nil {
+		return smtpRejection(fmt.Errorf("fail to finish message: %w", err))
+	}
+	return nil
+}
+
+// smtpRejection marks errors of permanent SMTP failures with ErrRejected
+func smtpRejection(err error) error {
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) && smtpErr.Code >= 500 {
+		return fmt.Errorf("%w: %w", ErrRejected, err)
+	}
+	return err
+}
+
+// FileTransport writes every message to its own .eml file in a directory,
+// which is meant for local runs and for checking rendered messages
+type FileTransport struct {
+	dir string
+}
+
+func (t *FileTransport) Send(from string, to []string, eml []byte) error {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	// written under another name first, so readers never see half a message
+	tmpPath := filepath.Join(t.dir, "."+name)
+	if err := os.WriteFile(tmpPath, eml, 0o640); err != nil {
+		return fmt.Errorf("fail to write message: %w", err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(t.dir, name)); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("fail to move message in place: %w", err)
+	}
+
+	slog.Debug("message written to file", slog.String("file", name), slog.String("from", from), slog.Any("to", to))
+	return nil
+}
+
+// LogTransport prints messages to the log instead of sending them
+type LogTransport struct{}
+
+func (t *LogTransport) Send(from string, to []string, eml []byte) error {
+	slog.Info("message sent to log", slog.String("from", from), slog.Any("to", to), slog.String("message", string(eml)))
+	return nil
+}
+
+// SendmailTransport pipes messages to a sendmail-compatible binary
+type SendmailTransport struct {
+	path string
+}
+
+// exit codes of sendmail from sysexits.h telling the message won't ever be
+// accepted
+const (
+	exDataErr = 65
+	exNoUser  = 67
+	exNoHost  = 68
+)
+
+func (t *SendmailTransport) Send(from string, to []string, eml []byte) error {
+	args := append([]string{"-i", "-f", from, "--"}, to...)
+	cmd := exec.Command(t.path, args...)
+	cmd.Stdin = bytes.NewReader(eml)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if output := strings.TrimSpace(stderr.String()); output != "" {
+			err = fmt.Errorf("%w: %s", err, output)
+		}
+		err = fmt.Errorf("sendmail failed: %w", err)
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			switch exitErr.ExitCode() {
+			case exDataErr, exNoUser, exNoHost:
+				return fmt.Errorf("%w: %w", ErrRejected, err)
+			}
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package mailer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// unsubscribeScopeAll cancels every new post notification of the user
+const unsubscribeScopeAll = "all"
+
+// unsubscribeMacSize is how many bytes of the HMAC are kept in a token
+const unsubscribeMacSize = 16
+
+// unsubscribeToken returns a token letting anyone holding it cancel the scope
+// of the subscription with the unsubscribe id
+func (m *Mailer) unsubscribeToken(unsubscribeId string, scope string) string {
+	return fmt.Sprintf("%s.%s.%s", unsubscribeId, scope,
+		base64.RawURLEncoding.EncodeToString(m.unsubscribeMac(unsubscribeId, scope)))
+}
+
+func (m *Mailer) unsubscribeMac(unsubscribeId string, scope string) []byte {
+	mac := hmac.New(sha256.New, m.unsubscribeSecret)
+	mac.Write([]byte(scope + "\x00" + unsubscribeId))
+	return mac.Sum(nil)[:unsubscribeMacSize]
+}
+
+// parseUnsubscribeToken checks the token was signed by the mailer and returns
+// the unsubscribe id and the scope it cancels
+func (m *Mailer) parseUnsubscribeToken(token string) (unsubscribeId string, scope string, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", "", fmt.Errorf("token must have 3 parts, got %d", len(parts))
+	}
+
+	unsubscribeId, scope = parts[0], parts[1]
+	if unsubscribeId == "" {
+		return "", "", fmt.Errorf("empty unsubscribe id")
+	}
+	if scope != unsubscribeScopeAll {
+		return "", "", fmt.Errorf("unknown scope '%s'", scope)
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode signature")
+	}
+
+	if !hmac.Equal(mac, m.unsubscribeMac(unsubscribeId, scope)) {
+		return "", "", fmt.Errorf("signature does not match")
+	}
+	return unsubscribeId, scope, nil
+}
+
+// getUnsubscribeId returns the id unsubscribe tokens of the user are signed over
+func (m *Mailer) getUnsubscribeId(userIdHash []byte) (string, error) {
+	var unsubscribeId string
+	if err := m.db.QueryRow(`SELECT unsubscribe_id FROM user_email_table WHERE user_id=? LIMIT 1;`, userIdHash).Scan(&unsubscribeId); err != nil {
+		return "", fmt.Errorf("failed to query user-email settings in db: %w", err)
+	}
+	return unsubscribeId, nil
+}
+
+// VerifyUnsubscribeToken checks the token is valid without acting on it
+func (m *Mailer) VerifyUnsubscribeToken(token string) error {
+	if _, _, err := m.parseUnsubscribeToken(token); err != nil {
+		return fmt.Errorf("invalid unsubscribe token: %s", err)
+	}
+	return nil
+}
+
+// Unsubscribe cancels the subscription the token was issued for, whichever
+// user id owns it by now
+func (m *Mailer) Unsubscribe(token string) (clientError error, serverError error) {
+	unsubscribeId, scope, err := m.parseUnsubscribeToken(token)
+	if err != nil {
+		return fmt.Errorf("invalid unsubscribe token: %s", err), nil
+	}
+
+	var userIdHash []byte
+	err = m.db.QueryRow(`SELECT user_id FROM user_email_table WHERE unsubscribe_id=? LIMIT 1;`, unsubscribeId).Scan(&userIdHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the address was dropped or changed since, so nothing is sent to it anyway
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user-email settings in db: %s", err)
+	}
+
+	switch scope {
+	case unsubscribeScopeAll:
+		if err = m.Subscribe(userIdHash, None); err != nil {
+			return nil, fmt.Errorf("failed to unsubscribe: %s", err)
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,38 @@
+package mapblock
+
+import (
+	"github.com/yuin/goldmark/ast"
+)
+
+// MapBlock represents an embedded map block in the AST
+type MapBlock struct {
+	ast.BaseBlock
+	Zoom    int
+	Lang    string
+	Markers []MapMarker
+	Tracks  []MapTrack
+}
+
+type MapMarker struct {
+	Lat            float64
+	Long           float64
+	AccuracyMeters int64
+	Label          string
+}
+
+type MapTrack struct {
+	Path  string
+	Label string
+}
+
+var KindMapBlock = ast.NewNodeKind("MapBlock")
+
+// Dump implements ast.Node.Dump
+func (n *MapBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// Kind implements ast.Node.Kind
+func (n *MapBlock) Kind() ast.NodeKind {
+	return KindMapBlock
+}
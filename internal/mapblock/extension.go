@@ -0,0 +1,33 @@
+package mapblock
+
+import (
+	"github.com/SayaAndy/saya-today-web/config"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// Extension that combines parser and renderer
+type MapExtension struct {
+	mapStorage config.MapStorageConfig
+	readFile   func(path string) ([]byte, error)
+}
+
+// NewMapExtension creates the map block extension, readFile is used to fetch GPX tracks from the storage
+func NewMapExtension(mapStorage config.MapStorageConfig, readFile func(path string) ([]byte, error)) goldmark.Extender {
+	return &MapExtension{mapStorage, readFile}
+}
+
+func (e *MapExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(
+			util.Prioritized(NewMapParser(), 500),
+		),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(
+			util.Prioritized(NewMapHTMLRenderer(e.mapStorage, e.readFile), 500),
+		),
+	)
+}
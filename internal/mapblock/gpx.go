@@ -0,0 +1,55 @@
+package mapblock
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Name string  `xml:"name"`
+}
+
+type gpxFile struct {
+	Waypoints []gpxPoint `xml:"wpt"`
+	Routes    []struct {
+		Points []gpxPoint `xml:"rtept"`
+	} `xml:"rte"`
+	Tracks []struct {
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+// parseGPX returns every track segment and route of a GPX file as a polyline,
+// along with its named waypoints
+func parseGPX(content []byte) (lines [][][2]float64, waypoints []MapMarker, err error) {
+	var gpx gpxFile
+	if err := xml.Unmarshal(content, &gpx); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal gpx: %w", err)
+	}
+
+	for _, track := range gpx.Tracks {
+		for _, segment := range track.Segments {
+			lines = append(lines, toLine(segment.Points))
+		}
+	}
+	for _, route := range gpx.Routes {
+		lines = append(lines, toLine(route.Points))
+	}
+	for _, waypoint := range gpx.Waypoints {
+		waypoints = append(waypoints, MapMarker{Lat: waypoint.Lat, Long: waypoint.Lon, Label: waypoint.Name})
+	}
+
+	return lines, waypoints, nil
+}
+
+func toLine(points []gpxPoint) [][2]float64 {
+	line := make([][2]float64, 0, len(points))
+	for _, point := range points {
+		line = append(line, [2]float64{point.Lat, point.Lon})
+	}
+	return line
+}
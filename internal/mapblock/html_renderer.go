@@ -0,0 +1,167 @@
+package mapblock
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/SayaAndy/saya-today-web/config"
+	"github.com/SayaAndy/saya-today-web/internal/htmlid"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+type MapHTMLRenderer struct {
+	html.Config
+	mapStorage config.MapStorageConfig
+	readFile   func(path string) ([]byte, error)
+}
+
+type mapMarkerData struct {
+	Lat            float64 `json:"lat"`
+	Long           float64 `json:"long"`
+	AccuracyMeters int64   `json:"accuracyMeters,omitempty"`
+	Label          string  `json:"label,omitempty"`
+}
+
+type mapTrackData struct {
+	Label string         `json:"label,omitempty"`
+	Lines [][][2]float64 `json:"lines"`
+}
+
+type mapData struct {
+	Zoom    int             `json:"zoom"`
+	Markers []mapMarkerData `json:"markers"`
+	Tracks  []mapTrackData  `json:"tracks"`
+}
+
+func NewMapHTMLRenderer(mapStorage config.MapStorageConfig, readFile func(path string) ([]byte, error), opts ...html.Option) renderer.NodeRenderer {
+	r := &MapHTMLRenderer{
+		Config:     html.NewConfig(),
+		mapStorage: mapStorage,
+		readFile:   readFile,
+	}
+	for _, opt := range opts {
+		opt.SetHTMLOption(&r.Config)
+	}
+	return r
+}
+
+func (r *MapHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindMapBlock, r.renderMap)
+}
+
+func (r *MapHTMLRenderer) renderMap(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	mapBlock := n.(*MapBlock)
+
+	data := mapData{Zoom: mapBlock.Zoom, Markers: []mapMarkerData{}, Tracks: []mapTrackData{}}
+	for _, marker := range mapBlock.Markers {
+		data.Markers = append(data.Markers, mapMarkerData(marker))
+	}
+
+	for _, track := range mapBlock.Tracks {
+		content, err := r.readFile(track.Path)
+		if err != nil {
+			slog.Warn("failed to read gpx track for map block", slog.String("path", track.Path), slog.String("error", err.Error()))
+			continue
+		}
+		lines, waypoints, err := parseGPX(content)
+		if err != nil {
+			slog.Warn("failed to parse gpx track for map block", slog.String("path", track.Path), slog.String("error", err.Error()))
+			continue
+		}
+		data.Tracks = append(data.Tracks, mapTrackData{Label: track.Label, Lines: lines})
+		for _, waypoint := range waypoints {
+			data.Markers = append(data.Markers, mapMarkerData(waypoint))
+		}
+	}
+
+	if len(data.Markers) == 0 && len(data.Tracks) == 0 {
+		return ast.WalkContinue, nil
+	}
+
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		slog.Warn("failed to marshal map block data", slog.String("error", err.Error()))
+		return ast.WalkContinue, nil
+	}
+
+	lang := mapBlock.Lang
+	if lang == "" {
+		lang = "en"
+	}
+
+	_, _ = w.WriteString(strings.NewReplacer(
+		"{id}", htmlid.New(8),
+		"{data}", string(dataJSON),
+		"{tiles}", r.mapStorage.BaseUrl+"/"+r.mapStorage.PMTiles,
+		"{lang}", lang,
+	).Replace(`
+<div class="inline-map-{id} bg-paper bg-background-dark w-full h-80 my-4 rounded-lg border-2 border-dotted border-main-soft z-10"></div>
+
+<script>
+(function() {
+	const data = {data};
+	const container = document.querySelector('.inline-map-{id}');
+	const map = L.map(container, { scrollWheelZoom: false });
+
+	protomapsL.leafletLayer({
+		url: '{tiles}',
+		maxZoom: 15,
+		flavor: window.matchMedia('(prefers-color-scheme: dark)').matches ? 'dark' : 'light',
+		lang: '{lang}'
+	}).addTo(map);
+
+	const bounds = L.latLngBounds([]);
+	const style = getComputedStyle(document.documentElement);
+	const color = style.getPropertyValue('--color-accent-deep') || '#c0392b';
+
+	data.tracks.forEach(track => {
+		track.lines.forEach(line => {
+			if (line.length === 0) return;
+			const polyline = L.polyline(line, { color: color, weight: 4, opacity: 0.8 }).addTo(map);
+			if (track.label) polyline.bindTooltip(track.label, { sticky: true });
+			bounds.extend(polyline.getBounds());
+		});
+	});
+
+	data.markers.forEach(marker => {
+		if (marker.accuracyMeters) {
+			L.circle([marker.lat, marker.long], {
+				color: color,
+				fillColor: color,
+				fillOpacity: 0.15,
+				radius: marker.accuracyMeters
+			}).addTo(map);
+		}
+		const point = L.circleMarker([marker.lat, marker.long], {
+			radius: 6, color: color, fillColor: color, fillOpacity: 0.9
+		}).addTo(map);
+		if (marker.label) point.bindTooltip(marker.label, { permanent: data.markers.length <= 5, direction: 'top' });
+		bounds.extend([marker.lat, marker.long]);
+	});
+
+	if (data.markers.length === 1 && data.tracks.length === 0) {
+		map.setView([data.markers[0].lat, data.markers[0].long], data.zoom);
+	} else {
+		map.fitBounds(bounds, { padding: [24, 24], maxZoom: data.zoom });
+	}
+
+	const resizeObserver = new ResizeObserver(() => map.invalidateSize());
+	resizeObserver.observe(container);
+
+	document.addEventListener('popout', () => {
+		resizeObserver.disconnect();
+		map.remove();
+	}, { once: true });
+})();
+</script>
+`))
+
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,117 @@
+package mapblock
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/SayaAndy/saya-today-web/internal/mdcontext"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+const defaultZoom = 8
+
+type MapParser struct {
+	openRe *regexp.Regexp
+}
+
+func NewMapParser() parser.BlockParser {
+	return &MapParser{
+		openRe: regexp.MustCompile(`^\{Map(?::([0-9]{1,2}))?\}$`),
+	}
+}
+
+func (p *MapParser) Trigger() []byte {
+	return []byte{'{'}
+}
+
+func (p *MapParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+
+	if !bytes.HasPrefix(line, []byte("{Map")) {
+		return nil, parser.NoChildren
+	}
+
+	trimmed := bytes.TrimSpace(line)
+	parts := p.openRe.FindSubmatch(trimmed)
+	if parts == nil {
+		slog.Warn("invalid map header format", slog.String("line", string(trimmed)))
+		return nil, parser.NoChildren
+	}
+
+	zoom := defaultZoom
+	if len(parts[1]) != 0 {
+		zoom, _ = strconv.Atoi(string(parts[1]))
+	}
+
+	return &MapBlock{Zoom: zoom, Lang: mdcontext.Lang(pc)}, parser.NoChildren
+}
+
+func (p *MapParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, segment := reader.PeekLine()
+	if len(line) == 0 || segment.Len() == 0 {
+		return parser.Continue | parser.NoChildren
+	}
+
+	trimmed := bytes.TrimSpace(line)
+	if bytes.Equal(trimmed, []byte("{/Map}")) {
+		reader.AdvanceLine()
+		return parser.Close
+	}
+	if len(trimmed) == 0 {
+		return parser.Continue | parser.NoChildren
+	}
+
+	mapBlock := node.(*MapBlock)
+
+	location, label, _ := strings.Cut(string(trimmed), "|")
+	location = strings.TrimSpace(location)
+	label = strings.TrimSpace(label)
+
+	if path, ok := strings.CutPrefix(location, "gpx:"); ok {
+		mapBlock.Tracks = append(mapBlock.Tracks, MapTrack{Path: strings.TrimSpace(path), Label: label})
+		return parser.Continue | parser.NoChildren
+	}
+
+	locationParts := strings.Fields(location)
+	if len(locationParts) < 2 {
+		slog.Warn("invalid map marker format, expected 'lat long [accuracy] | label'", slog.String("line", string(trimmed)))
+		return parser.Continue | parser.NoChildren
+	}
+
+	lat, latErr := strconv.ParseFloat(locationParts[0], 64)
+	long, longErr := strconv.ParseFloat(locationParts[1], 64)
+	if latErr != nil || longErr != nil {
+		slog.Warn("invalid map marker coordinates", slog.String("line", string(trimmed)))
+		return parser.Continue | parser.NoChildren
+	}
+
+	var accuracyMeters int64
+	if len(locationParts) >= 3 {
+		accuracyMeters, _ = strconv.ParseInt(locationParts[2], 10, 64)
+	}
+
+	mapBlock.Markers = append(mapBlock.Markers, MapMarker{
+		Lat:            lat,
+		Long:           long,
+		AccuracyMeters: accuracyMeters,
+		Label:          label,
+	})
+
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *MapParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+}
+
+func (p *MapParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *MapParser) CanAcceptIndentedLine() bool {
+	return false
+}
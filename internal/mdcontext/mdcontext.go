@@ -0,0 +1,34 @@
+package mdcontext
+
+import (
+	"github.com/yuin/goldmark/parser"
+)
+
+var langContextKey = parser.NewContextKey()
+
+// SetLang stores the language of the document being converted, so parsers and
+// renderers of custom blocks can localize their output
+func SetLang(pc parser.Context, lang string) {
+	pc.Set(langContextKey, lang)
+}
+
+// Lang returns the language of the document being converted or an empty string
+// if it was not set
+func Lang(pc parser.Context) string {
+	lang, _ := pc.Get(langContextKey).(string)
+	return lang
+}
+
+var codenameContextKey = parser.NewContextKey()
+
+// SetCodename stores the codename of the blog post being converted
+func SetCodename(pc parser.Context, codename string) {
+	pc.Set(codenameContextKey, codename)
+}
+
+// Codename returns the codename of the blog post being converted or an empty
+// string if it was not set
+func Codename(pc parser.Context) string {
+	codename, _ := pc.Get(codenameContextKey).(string)
+	return codename
+}
@@ -0,0 +1,42 @@
+package media
+
+import (
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	Video = "Video"
+	Audio = "Audio"
+	Embed = "Embed"
+)
+
+// MediaBlock represents a video, audio or external embed block in the AST
+type MediaBlock struct {
+	ast.BaseBlock
+	MediaKind string
+	Namespace string
+	Sources   []string
+	Poster    string
+	Tracks    []MediaTrack
+	Caption   []byte
+	URL       string
+	Lang      string
+}
+
+type MediaTrack struct {
+	Path    string
+	SrcLang string
+	Label   string
+}
+
+var KindMediaBlock = ast.NewNodeKind("MediaBlock")
+
+// Dump implements ast.Node.Dump
+func (n *MediaBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"MediaKind": n.MediaKind}, nil)
+}
+
+// Kind implements ast.Node.Kind
+func (n *MediaBlock) Kind() ast.NodeKind {
+	return KindMediaBlock
+}
@@ -0,0 +1,32 @@
+package media
+
+import (
+	"github.com/SayaAndy/saya-today-web/config"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// Extension that combines parser and renderer
+type MediaExtension struct {
+	photoStorage config.PhotoStorageConfig
+	mediaStorage config.MediaStorageConfig
+}
+
+func NewMediaExtension(photoStorage config.PhotoStorageConfig, mediaStorage config.MediaStorageConfig) goldmark.Extender {
+	return &MediaExtension{photoStorage, mediaStorage}
+}
+
+func (e *MediaExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(
+			util.Prioritized(NewMediaParser(), 500),
+		),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(
+			util.Prioritized(NewMediaHTMLRenderer(e.photoStorage, e.mediaStorage), 500),
+		),
+	)
+}
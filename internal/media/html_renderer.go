@@ -0,0 +1,220 @@
+package media
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/SayaAndy/saya-today-web/config"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+var mimeTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".webm": "video/webm",
+	".ogv":  "video/ogg",
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".ogg":  "audio/ogg",
+	".opus": "audio/ogg",
+	".wav":  "audio/wav",
+	".flac": "audio/flac",
+}
+
+type MediaHTMLRenderer struct {
+	html.Config
+	photoStorage config.PhotoStorageConfig
+	mediaStorage config.MediaStorageConfig
+}
+
+// NewMediaHTMLRenderer renders posters from the photo storage, while the video
+// and audio files themselves come from the media storage
+func NewMediaHTMLRenderer(photoStorage config.PhotoStorageConfig, mediaStorage config.MediaStorageConfig, opts ...html.Option) renderer.NodeRenderer {
+	r := &MediaHTMLRenderer{
+		Config:       html.NewConfig(),
+		photoStorage: photoStorage,
+		mediaStorage: mediaStorage,
+	}
+	for _, opt := range opts {
+		opt.SetHTMLOption(&r.Config)
+	}
+	return r
+}
+
+func (r *MediaHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindMediaBlock, r.renderMedia)
+}
+
+func (r *MediaHTMLRenderer) renderMedia(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	media := n.(*MediaBlock)
+
+	lang := media.Lang
+	if lang == "" {
+		lang = "en"
+	}
+
+	switch media.MediaKind {
+	case Video, Audio:
+		if len(media.Sources) == 0 {
+			slog.Warn("media block has no sources", slog.String("kind", media.MediaKind))
+			return ast.WalkContinue, nil
+		}
+		_, _ = w.WriteString(`<figure class="flex flex-col items-center mx-auto mb-8 w-full">`)
+		r.renderPlayer(w, media, lang)
+	case Embed:
+		if media.URL == "" {
+			slog.Warn("embed block has no url")
+			return ast.WalkContinue, nil
+		}
+		_, _ = w.WriteString(`<figure class="flex flex-col items-center mx-auto mb-8 w-full">`)
+		r.renderEmbed(w, media, lang)
+	}
+
+	if len(media.Caption) != 0 {
+		_, _ = w.WriteString(`<figcaption class="text-base font-gentium italic text-secondary text-center mt-1">`)
+		_, _ = w.Write(util.EscapeHTML(media.Caption))
+		_, _ = w.WriteString(`</figcaption>`)
+	}
+	_, _ = w.WriteString("</figure>\n")
+
+	return ast.WalkContinue, nil
+}
+
+func (r *MediaHTMLRenderer) renderPlayer(w util.BufWriter, media *MediaBlock, lang string) {
+	tag := strings.ToLower(media.MediaKind)
+
+	_, _ = w.WriteString("<" + tag + ` controls preload="metadata"`)
+	if media.MediaKind == Video {
+		_, _ = w.WriteString(` playsinline class="max-w-full max-h-[80dvh] rounded-lg shadow-lg bg-paper bg-background-dark"`)
+		if media.Poster != "" {
+			poster := media.Namespace + media.Poster
+			poster = strings.TrimSuffix(poster, path.Ext(poster))
+			_, _ = w.WriteString(` poster="`)
+			_, _ = w.Write(util.EscapeHTML([]byte(r.photoStorage.VariantUrl(1200, poster))))
+			_, _ = w.WriteString(`"`)
+		}
+	} else {
+		_, _ = w.WriteString(` class="w-full max-w-xl"`)
+	}
+	_, _ = w.WriteString(">\n")
+
+	for _, src := range media.Sources {
+		_, _ = w.WriteString(`<source src="`)
+		_, _ = w.Write(util.EscapeHTML([]byte(r.mediaUrl(media.Namespace, src))))
+		_, _ = w.WriteString(`"`)
+		if mimeType, ok := mimeTypes[strings.ToLower(path.Ext(src))]; ok {
+			_, _ = w.WriteString(` type="` + mimeType + `"`)
+		}
+		_, _ = w.WriteString(" />\n")
+	}
+
+	defaultTrack := 0
+	for i, track := range media.Tracks {
+		if track.SrcLang == lang {
+			defaultTrack = i
+			break
+		}
+	}
+
+	for i, track := range media.Tracks {
+		_, _ = w.WriteString(`<track kind="subtitles" src="`)
+		_, _ = w.Write(util.EscapeHTML([]byte(r.mediaUrl(media.Namespace, track.Path))))
+		_, _ = w.WriteString(`"`)
+		srcLang := track.SrcLang
+		if srcLang == "" {
+			srcLang = lang
+		}
+		_, _ = w.WriteString(` srclang="`)
+		_, _ = w.Write(util.EscapeHTML([]byte(srcLang)))
+		_, _ = w.WriteString(`"`)
+		if track.Label != "" {
+			_, _ = w.WriteString(` label="`)
+			_, _ = w.Write(util.EscapeHTML([]byte(track.Label)))
+			_, _ = w.WriteString(`"`)
+		}
+		if i == defaultTrack {
+			_, _ = w.WriteString(" default")
+		}
+		_, _ = w.WriteString(" />\n")
+	}
+
+	notSupported, _ := l10n.T.GetDefaultPath("", lang, "Media", "NotSupported").(string)
+	_, _ = w.WriteString(notSupported)
+	_, _ = w.WriteString("</" + tag + ">")
+}
+
+func (r *MediaHTMLRenderer) renderEmbed(w util.BufWriter, media *MediaBlock, lang string) {
+	embedUrl, host, err := toEmbedUrl(media.URL)
+	if err != nil {
+		slog.Warn("invalid embed url", slog.String("url", media.URL), slog.String("error", err.Error()))
+		return
+	}
+
+	// without a translation the button still names the host it loads from
+	loadEmbed, ok := l10n.T.GetDefaultPath("%s", lang, "Media", "LoadEmbed").(string)
+	if !ok {
+		loadEmbed = "%s"
+	}
+
+	_, _ = w.WriteString(`<div class="relative w-full max-w-3xl aspect-video rounded-lg shadow-lg overflow-hidden bg-paper bg-background-dark">`)
+	_, _ = w.WriteString(`<button type="button" data-src="`)
+	_, _ = w.Write(util.EscapeHTML([]byte(embedUrl)))
+	_, _ = w.WriteString(`" class="absolute inset-0 flex flex-col items-center justify-center gap-2 p-4 cursor-pointer text-main-hard hover:bg-accent-light/40" `)
+	_, _ = w.WriteString(`onclick="const f = document.createElement('iframe'); f.src = this.dataset.src; f.className = 'absolute inset-0 w-full h-full'; f.allow = 'autoplay; encrypted-media; picture-in-picture; fullscreen'; f.allowFullscreen = true; f.referrerPolicy = 'strict-origin-when-cross-origin'; this.replaceWith(f);">`)
+	_, _ = w.WriteString(`<span class="text-4xl select-none">▶</span>`)
+	_, _ = w.WriteString(`<span class="font-gentium font-bold text-lg">`)
+	_, _ = w.Write(util.EscapeHTML(fmt.Appendf(nil, loadEmbed, host)))
+	_, _ = w.WriteString(`</span>`)
+	if embedNotice, _ := l10n.T.GetDefaultPath("", lang, "Media", "EmbedNotice").(string); embedNotice != "" {
+		_, _ = w.WriteString(`<span class="font-gentium text-sm text-secondary">`)
+		_, _ = w.Write(util.EscapeHTML(fmt.Appendf(nil, embedNotice, host)))
+		_, _ = w.WriteString(`</span>`)
+	}
+	_, _ = w.WriteString(`</button></div>`)
+}
+
+func (r *MediaHTMLRenderer) mediaUrl(namespace string, src string) string {
+	if strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "http://") {
+		return src
+	}
+	return r.mediaStorage.BaseUrl + "/" + namespace + src
+}
+
+// toEmbedUrl turns links to known video hosts into their privacy-enhanced
+// player urls, other https links are embedded as they are
+func toEmbedUrl(rawUrl string) (embedUrl string, host string, err error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme != "https" {
+		return "", "", fmt.Errorf("only https embeds are allowed, got '%s' scheme", u.Scheme)
+	}
+	host = strings.TrimPrefix(u.Hostname(), "www.")
+
+	switch host {
+	case "youtube.com", "m.youtube.com":
+		if id := u.Query().Get("v"); id != "" {
+			return "https://www.youtube-nocookie.com/embed/" + url.PathEscape(id) + "?autoplay=1", "youtube.com", nil
+		}
+		if id, ok := strings.CutPrefix(u.Path, "/shorts/"); ok {
+			return "https://www.youtube-nocookie.com/embed/" + url.PathEscape(id) + "?autoplay=1", "youtube.com", nil
+		}
+	case "youtu.be":
+		return "https://www.youtube-nocookie.com/embed/" + url.PathEscape(strings.Trim(u.Path, "/")) + "?autoplay=1", "youtube.com", nil
+	case "vimeo.com":
+		return "https://player.vimeo.com/video/" + url.PathEscape(strings.Trim(u.Path, "/")) + "?dnt=1&autoplay=1", "vimeo.com", nil
+	}
+
+	return u.String(), host, nil
+}
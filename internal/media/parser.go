@@ -0,0 +1,114 @@
+package media
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/SayaAndy/saya-today-web/internal/mdcontext"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+type MediaParser struct {
+	openRe *regexp.Regexp
+}
+
+func NewMediaParser() parser.BlockParser {
+	return &MediaParser{
+		openRe: regexp.MustCompile(`^\{(Video|Audio|Embed)(?::([A-Za-z0-9\+\-/]+))?\}$`),
+	}
+}
+
+func (p *MediaParser) Trigger() []byte {
+	return []byte{'{'}
+}
+
+func (p *MediaParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+
+	if !bytes.HasPrefix(line, []byte("{Video")) && !bytes.HasPrefix(line, []byte("{Audio")) && !bytes.HasPrefix(line, []byte("{Embed")) {
+		return nil, parser.NoChildren
+	}
+
+	trimmed := bytes.TrimSpace(line)
+	parts := p.openRe.FindSubmatch(trimmed)
+	if parts == nil {
+		slog.Warn("invalid media header format", slog.String("line", string(trimmed)))
+		return nil, parser.NoChildren
+	}
+
+	return &MediaBlock{
+		MediaKind: string(parts[1]),
+		Namespace: string(parts[2]),
+		Lang:      mdcontext.Lang(pc),
+	}, parser.NoChildren
+}
+
+func (p *MediaParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, segment := reader.PeekLine()
+	if len(line) == 0 || segment.Len() == 0 {
+		return parser.Continue | parser.NoChildren
+	}
+
+	media := node.(*MediaBlock)
+
+	trimmed := bytes.TrimSpace(line)
+	if bytes.Equal(trimmed, []byte("{/"+media.MediaKind+"}")) {
+		reader.AdvanceLine()
+		return parser.Close
+	}
+	if len(trimmed) == 0 {
+		return parser.Continue | parser.NoChildren
+	}
+
+	key, value, hasKey := strings.Cut(string(trimmed), ":")
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+	if !hasKey || strings.ContainsAny(key, "./ ") {
+		key, value = "", string(trimmed)
+	}
+
+	switch key {
+	case "poster":
+		media.Poster = value
+	case "caption":
+		media.Caption = []byte(value)
+	case "track":
+		trackParts := strings.SplitN(value, "|", 3)
+		track := MediaTrack{Path: strings.TrimSpace(trackParts[0])}
+		if len(trackParts) >= 2 {
+			track.SrcLang = strings.TrimSpace(trackParts[1])
+		}
+		if len(trackParts) >= 3 {
+			track.Label = strings.TrimSpace(trackParts[2])
+		}
+		media.Tracks = append(media.Tracks, track)
+	case "", "https", "http":
+		if key != "" {
+			value = string(trimmed)
+		}
+		if media.MediaKind == Embed {
+			media.URL = value
+		} else {
+			media.Sources = append(media.Sources, value)
+		}
+	default:
+		slog.Warn("unknown media block option", slog.String("kind", media.MediaKind), slog.String("line", string(trimmed)))
+	}
+
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *MediaParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+}
+
+func (p *MediaParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *MediaParser) CanAcceptIndentedLine() bool {
+	return false
+}
@@ -0,0 +1,31 @@
+package ogcard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	Width  = 1200
+	Height = 630
+
+	// layoutVersion is mixed into the cache key, bump it whenever drawing
+	// changes so the cards on disk get regenerated
+	layoutVersion = "1"
+)
+
+// Card is the content of a single share card, all fields except Title are optional
+type Card struct {
+	Title     string
+	Subtitle  string
+	Date      string
+	Thumbnail string
+}
+
+// Key identifies the rendered card on disk, it changes together with any of
+// the card fields
+func (c Card) Key() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{layoutVersion, c.Title, c.Subtitle, c.Date, c.Thumbnail}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
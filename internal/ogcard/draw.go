@@ -0,0 +1,187 @@
+package ogcard
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// approximations of the olive theme colours from static/input.css
+var (
+	colorPaper       = color.RGBA{0xfd, 0xfd, 0xec, 0xff}
+	colorInk         = color.RGBA{0x46, 0x4e, 0x35, 0xff}
+	colorInkSoft     = color.RGBA{0x8c, 0x9f, 0x8b, 0xff}
+	colorAccentDeep  = color.RGBA{0x5e, 0xa5, 0x00, 0xff}
+	colorAccentLight = color.RGBA{0xbb, 0xf4, 0x51, 0xff}
+)
+
+const (
+	padding        = 72
+	thumbnailLeft  = 760
+	accentStripe   = 12
+	footerBaseline = Height - 64
+)
+
+var titleSizes = []float64{76, 64, 54, 46}
+
+type fonts struct {
+	bold    *opentype.Font
+	regular *opentype.Font
+	italic  *opentype.Font
+}
+
+var loadFonts = sync.OnceValues(func() (*fonts, error) {
+	var f fonts
+	var err error
+	if f.bold, err = opentype.Parse(gobold.TTF); err != nil {
+		return nil, fmt.Errorf("parse bold font: %w", err)
+	}
+	if f.regular, err = opentype.Parse(goregular.TTF); err != nil {
+		return nil, fmt.Errorf("parse regular font: %w", err)
+	}
+	if f.italic, err = opentype.Parse(goitalic.TTF); err != nil {
+		return nil, fmt.Errorf("parse italic font: %w", err)
+	}
+	return &f, nil
+})
+
+func newFace(f *opentype.Font, size float64) (font.Face, error) {
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+// Draw renders the card, thumbnail may be nil
+func Draw(card Card, siteName string, thumbnail image.Image) (*image.RGBA, error) {
+	f, err := loadFonts()
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	fill(img, img.Bounds(), colorPaper)
+
+	textRight := Width - padding
+	if thumbnail != nil {
+		drawCover(img, image.Rect(thumbnailLeft, 0, Width, Height), thumbnail)
+		fill(img, image.Rect(thumbnailLeft-accentStripe, 0, thumbnailLeft, Height), colorAccentDeep)
+		textRight = thumbnailLeft - accentStripe - padding*2/3
+	}
+	maxWidth := fixed.I(textRight - padding)
+
+	fill(img, image.Rect(padding, padding, padding+96, padding+10), colorAccentDeep)
+	y := padding + 10
+
+	titleFace, titleLines, err := fitTitle(f.bold, card.Title, maxWidth)
+	if err != nil {
+		return nil, err
+	}
+	defer titleFace.Close()
+	y = drawLines(img, titleFace, titleLines, colorInk, padding, y)
+
+	if card.Subtitle != "" {
+		subtitleFace, err := newFace(f.italic, 34)
+		if err != nil {
+			return nil, fmt.Errorf("create subtitle face: %w", err)
+		}
+		defer subtitleFace.Close()
+		lines, _ := wrap(subtitleFace, card.Subtitle, maxWidth, 2)
+		y = drawLines(img, subtitleFace, lines, colorAccentDeep, padding, y+8)
+	}
+
+	siteFace, err := newFace(f.bold, 34)
+	if err != nil {
+		return nil, fmt.Errorf("create site name face: %w", err)
+	}
+	defer siteFace.Close()
+	drawString(img, siteFace, siteName, colorInk, padding, footerBaseline)
+	siteWidth := font.MeasureString(siteFace, siteName).Round()
+	fill(img, image.Rect(padding, footerBaseline+14, padding+siteWidth, footerBaseline+22), colorAccentLight)
+
+	if card.Date != "" {
+		dateFace, err := newFace(f.regular, 30)
+		if err != nil {
+			return nil, fmt.Errorf("create date face: %w", err)
+		}
+		defer dateFace.Close()
+		if baseline := footerBaseline - 64; baseline > y+dateFace.Metrics().Ascent.Round() {
+			drawString(img, dateFace, card.Date, colorInkSoft, padding, baseline)
+		}
+	}
+
+	return img, nil
+}
+
+// fitTitle picks the largest title size that fits into three lines, falling
+// back to four truncated lines of the smallest one
+func fitTitle(f *opentype.Font, title string, maxWidth fixed.Int26_6) (font.Face, []string, error) {
+	for i, size := range titleSizes {
+		face, err := newFace(f, size)
+		if err != nil {
+			return nil, nil, fmt.Errorf("create title face: %w", err)
+		}
+		maxLines := 3
+		if i == len(titleSizes)-1 {
+			maxLines = 4
+		}
+		lines, truncated := wrap(face, title, maxWidth, maxLines)
+		if !truncated || i == len(titleSizes)-1 {
+			return face, lines, nil
+		}
+		face.Close()
+	}
+	return nil, nil, fmt.Errorf("no title sizes configured")
+}
+
+// drawLines draws lines below y and returns the y of the last line bottom
+func drawLines(img *image.RGBA, face font.Face, lines []string, c color.Color, x int, y int) int {
+	metrics := face.Metrics()
+	lineHeight := metrics.Height.Round() * 6 / 5
+	for _, line := range lines {
+		y += lineHeight
+		drawString(img, face, line, c, x, y-metrics.Descent.Round())
+	}
+	return y
+}
+
+func drawString(img *image.RGBA, face font.Face, s string, c color.Color, x int, baseline int) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, baseline),
+	}
+	d.DrawString(s)
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// drawCover scales src to cover r completely, cropping it around the centre
+func drawCover(dst *image.RGBA, r image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	if sb.Empty() {
+		return
+	}
+
+	crop := sb
+	if sb.Dx()*r.Dy() > sb.Dy()*r.Dx() {
+		w := sb.Dy() * r.Dx() / r.Dy()
+		crop.Min.X = sb.Min.X + (sb.Dx()-w)/2
+		crop.Max.X = crop.Min.X + w
+	} else {
+		h := sb.Dx() * r.Dy() / r.Dx()
+		crop.Min.Y = sb.Min.Y + (sb.Dy()-h)/2
+		crop.Max.Y = crop.Min.Y + h
+	}
+
+	draw.CatmullRom.Scale(dst, r, src, crop, draw.Src, nil)
+}
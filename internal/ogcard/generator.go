@@ -0,0 +1,117 @@
+package ogcard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/config"
+	_ "golang.org/x/image/webp"
+)
+
+type Generator struct {
+	cacheDir string
+	siteName string
+	client   *http.Client
+}
+
+func NewGenerator(cfg config.ShareCardConfig) (*Generator, error) {
+	if err := os.MkdirAll(cfg.CacheDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create share card cache directory '%s': %w", cfg.CacheDir, err)
+	}
+
+	return &Generator{
+		cacheDir: cfg.CacheDir,
+		siteName: cfg.SiteName,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// Get returns the PNG encoded card, either from the disk cache or freshly drawn.
+// A card whose thumbnail failed to load is drawn without it and not cached, so
+// that the next request tries again
+func (g *Generator) Get(card Card) ([]byte, error) {
+	path := filepath.Join(g.cacheDir, card.Key()+".png")
+	if content, err := os.ReadFile(path); err == nil {
+		return content, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("failed to read cached share card", slog.String("path", path), slog.String("error", err.Error()))
+	}
+
+	var thumbnail image.Image
+	toCache := true
+	if card.Thumbnail != "" {
+		var err error
+		if thumbnail, err = g.fetchImage(card.Thumbnail); err != nil {
+			slog.Warn("failed to load share card thumbnail, drawing without it",
+				slog.String("url", card.Thumbnail), slog.String("error", err.Error()))
+			toCache = false
+		}
+	}
+
+	img, err := Draw(card, g.siteName, thumbnail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to draw share card: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode share card: %w", err)
+	}
+
+	if toCache {
+		if err := writeAtomically(path, buf.Bytes()); err != nil {
+			slog.Warn("failed to cache share card", slog.String("path", path), slog.String("error", err.Error()))
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (g *Generator) fetchImage(url string) (image.Image, error) {
+	resp, err := g.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("request image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request image: unexpected status %d", resp.StatusCode)
+	}
+
+	img, _, err := image.Decode(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	return img, nil
+}
+
+func writeAtomically(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temporary file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename temporary file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package ogcard
+
+import (
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const ellipsis = "…"
+
+// wrap breaks text into lines no wider than maxWidth, words too long for a
+// single line are broken by runes. If the text needs more than maxLines lines,
+// the last one is cut and ends with an ellipsis
+func wrap(face font.Face, text string, maxWidth fixed.Int26_6, maxLines int) (lines []string, truncated bool) {
+	lines = make([]string, 0, maxLines)
+	current := ""
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if font.MeasureString(face, candidate) <= maxWidth {
+			current = candidate
+			continue
+		}
+
+		if current != "" {
+			lines = append(lines, current)
+			current = ""
+		}
+		for font.MeasureString(face, word) > maxWidth {
+			head, tail := splitToFit(face, word, maxWidth)
+			lines = append(lines, head)
+			word = tail
+		}
+		current = word
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	if len(lines) <= maxLines {
+		return lines, false
+	}
+
+	lines = lines[:maxLines]
+	lines[maxLines-1] = withEllipsis(face, lines[maxLines-1], maxWidth)
+	return lines, true
+}
+
+// splitToFit returns the longest prefix of word fitting into maxWidth (at least
+// one rune) and the rest of it
+func splitToFit(face font.Face, word string, maxWidth fixed.Int26_6) (head string, tail string) {
+	runes := []rune(word)
+	n := 1
+	for n < len(runes) && font.MeasureString(face, string(runes[:n+1])) <= maxWidth {
+		n++
+	}
+	return string(runes[:n]), string(runes[n:])
+}
+
+func withEllipsis(face font.Face, line string, maxWidth fixed.Int26_6) string {
+	runes := []rune(strings.TrimRight(line, " "))
+	for len(runes) > 0 && font.MeasureString(face, string(runes)+ellipsis) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimRight(string(runes), " ,.:;-—") + ellipsis
+}
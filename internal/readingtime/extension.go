@@ -0,0 +1,22 @@
+package readingtime
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/util"
+)
+
+// Extension that counts words and images of a document into the parser context
+type ReadingTimeExtension struct{}
+
+func NewReadingTimeExtension() goldmark.Extender {
+	return &ReadingTimeExtension{}
+}
+
+func (e *ReadingTimeExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithASTTransformers(
+			util.Prioritized(&ReadingTimeTransformer{}, 900),
+		),
+	)
+}
@@ -0,0 +1,52 @@
+package readingtime
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/SayaAndy/saya-today-web/internal/corpus"
+)
+
+// Index keeps reading stats of all posts of every language, collecting the
+// stats of a language again whenever the corpus parses its posts again
+type Index struct {
+	corpus *corpus.Corpus
+	mu     sync.Mutex
+	langs  map[string]*langIndex
+}
+
+type langIndex struct {
+	snapshot *corpus.Snapshot
+	stats    map[string]Stats
+}
+
+func NewIndex(corpus *corpus.Corpus) *Index {
+	return &Index{
+		corpus: corpus,
+		langs:  make(map[string]*langIndex),
+	}
+}
+
+// Get returns reading stats of the post with the codename
+func (i *Index) Get(lang string, codename string) (stats Stats, ok bool) {
+	snapshot, err := i.corpus.Get(lang)
+	if err != nil {
+		slog.Warn("failed to build reading time index", slog.String("lang", lang), slog.String("error", err.Error()))
+		return Stats{}, false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	index, found := i.langs[lang]
+	if !found || index.snapshot != snapshot {
+		index = &langIndex{snapshot: snapshot, stats: make(map[string]Stats, len(snapshot.Posts))}
+		for _, post := range snapshot.Posts {
+			index.stats[post.Page.FileName] = Get(post.Context)
+		}
+		i.langs[lang] = index
+	}
+
+	stats, ok = index.stats[codename]
+	return stats, ok
+}
@@ -0,0 +1,73 @@
+package readingtime
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// Cyrillic words are longer on average, so fewer of them are read per minute
+	latinWordsPerMinute    = 230
+	cyrillicWordsPerMinute = 180
+
+	// the first image of a post takes firstImageTime to look at, every next one
+	// takes a second less, but never less than minImageTime
+	firstImageTime = 12 * time.Second
+	minImageTime   = 3 * time.Second
+)
+
+type Stats struct {
+	Words         int           `json:"words"`
+	LatinWords    int           `json:"latinWords"`
+	CyrillicWords int           `json:"cyrillicWords"`
+	Images        int           `json:"images"`
+	Duration      time.Duration `json:"duration"`
+}
+
+// Minutes returns the reading time rounded up to whole minutes, at least one
+func (s Stats) Minutes() int {
+	return max(1, int(math.Ceil(s.Duration.Minutes())))
+}
+
+// ISO8601 returns the reading time as an ISO 8601 duration, as expected by
+// timeRequired in schema.org
+func (s Stats) ISO8601() string {
+	return fmt.Sprintf("PT%dM", s.Minutes())
+}
+
+func (s *Stats) addText(text string) {
+	for _, word := range strings.Fields(text) {
+		hasLetter, isCyrillic := false, false
+		for _, r := range word {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				hasLetter = true
+			}
+			if unicode.Is(unicode.Cyrillic, r) {
+				isCyrillic = true
+				break
+			}
+		}
+		if !hasLetter {
+			continue
+		}
+
+		s.Words++
+		if isCyrillic {
+			s.CyrillicWords++
+		} else {
+			s.LatinWords++
+		}
+	}
+}
+
+func (s *Stats) calculateDuration() {
+	minutes := float64(s.LatinWords)/latinWordsPerMinute + float64(s.CyrillicWords)/cyrillicWordsPerMinute
+	s.Duration = time.Duration(minutes * float64(time.Minute))
+
+	for i := range s.Images {
+		s.Duration += max(firstImageTime-time.Duration(i)*time.Second, minImageTime)
+	}
+}
@@ -0,0 +1,44 @@
+package readingtime
+
+import (
+	"github.com/SayaAndy/saya-today-web/internal/glightbox"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+var statsContextKey = parser.NewContextKey()
+
+type ReadingTimeTransformer struct{}
+
+func (t *ReadingTimeTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	stats := Stats{}
+
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.Text:
+			stats.addText(string(node.Segment.Value(source)))
+		case *ast.String:
+			stats.addText(string(node.Value))
+		case *ast.Image:
+			stats.Images++
+		case *glightbox.GLightboxBlock:
+			stats.Images += len(node.Images)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	stats.calculateDuration()
+	pc.Set(statsContextKey, stats)
+}
+
+// Get returns reading stats of the parsed document
+func Get(pc parser.Context) Stats {
+	stats, _ := pc.Get(statsContextKey).(Stats)
+	return stats
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/gofiber/fiber/v2"
+)
+
+type BacklinksHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &BacklinksHandler{})
+}
+
+func (r *BacklinksHandler) Filter() (method string, path string) {
+	return "GET", "/api/v1/blog/backlinks"
+}
+
+func (r *BacklinksHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *BacklinksHandler) TemplatesToInject() []string {
+	return []string{"views/partials/blog-page-backlinks.html"}
+}
+
+func (r *BacklinksHandler) ToCache() router.CacheSetting {
+	return router.ByUrlAndQuery
+}
+
+func (r *BacklinksHandler) CacheDuration() time.Duration {
+	return 15 * time.Minute
+}
+
+func (r *BacklinksHandler) ToValidateLang() router.LangSetting {
+	return router.InForm
+}
+
+func (r *BacklinksHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterLoose
+}
+
+func (r *BacklinksHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	codename := c.Query("codename")
+	if codename == "" {
+		return fiber.StatusBadRequest, fmt.Errorf("'codename' query parameter is required")
+	}
+
+	pages, err := supplements.LinkGraph.Backlinks(lang, codename)
+	if err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to get backlinks for '%s' post: %w", codename, err)
+	}
+
+	backlinks := make([]fiber.Map, 0, len(pages))
+	for _, page := range pages {
+		backlinks = append(backlinks, fiber.Map{
+			"ArticleLink": "/" + lang + "/blog/" + page.FileName,
+			"Title":       page.Metadata.Title,
+			"ActionDate":  page.Metadata.ActionDate,
+			"Thumbnail":   page.Metadata.Thumbnail,
+		})
+	}
+
+	templateMap["Backlinks"] = backlinks
+
+	return fiber.StatusOK, nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/internal/wikilink"
+	"github.com/gofiber/fiber/v2"
+)
+
+type BrokenLinksHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &BrokenLinksHandler{})
+}
+
+func (r *BrokenLinksHandler) Filter() (method string, path string) {
+	return "GET", "/api/v1/blog/broken-links"
+}
+
+func (r *BrokenLinksHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *BrokenLinksHandler) ToCache() router.CacheSetting {
+	return router.ByUrlOnly
+}
+
+func (r *BrokenLinksHandler) CacheDuration() time.Duration {
+	return 15 * time.Minute
+}
+
+func (r *BrokenLinksHandler) ToValidateLang() router.LangSetting {
+	return router.NotRequired
+}
+
+func (r *BrokenLinksHandler) ContentType() string {
+	return fiber.MIMEApplicationJSONCharsetUTF8
+}
+
+func (r *BrokenLinksHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterStrict
+}
+
+func (r *BrokenLinksHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	type BrokenLink struct {
+		Lang     string `json:"lang"`
+		Codename string `json:"codename"`
+		Target   string `json:"target"`
+	}
+
+	brokenLinks := make([]BrokenLink, 0)
+	for _, availableLang := range supplements.AvailableLanguages {
+		snapshot, err := supplements.Corpus.Get(availableLang.Name)
+		if err != nil {
+			return fiber.StatusInternalServerError, fmt.Errorf("failed to scan pages for '%s' lang: %w", availableLang.Name, err)
+		}
+
+		for _, post := range snapshot.Posts {
+			for _, target := range wikilink.Broken(post.Context) {
+				brokenLinks = append(brokenLinks, BrokenLink{Lang: availableLang.Name, Codename: post.Page.FileName, Target: target})
+			}
+		}
+	}
+
+	output, err := json.Marshal(map[string]any{"brokenLinks": brokenLinks})
+	if err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to marshal broken links: %w", err)
+	}
+	templateMap["Output"] = output
+
+	return fiber.StatusOK, nil
+}
@@ -87,6 +87,7 @@ func (r *BlogSearchHandler) Render(c *fiber.Ctx, supplements *router.Supplements
 
 	pageMeta := make([]fiber.Map, 0, len(pages))
 	for _, page := range pages {
+		stats, _ := supplements.ReadingTime.Get(lang, page.FileName)
 		for _, tag := range page.Metadata.Tags {
 			if len(tags) == 0 || slices.Contains(tags, tag) {
 				if medley == "" || medley == page.Metadata.Medley {
@@ -100,12 +101,16 @@ func (r *BlogSearchHandler) Render(c *fiber.Ctx, supplements *router.Supplements
 						"Thumbnail":        page.Metadata.Thumbnail,
 						"Tags":             page.Metadata.Tags,
 						"LikeCount":        supplements.ClientCache.GetLikeCount(page.FileName),
-						"Liked":            supplements.ClientCache.GetLikeStatus(c.IP(), page.FileName),
+						"Liked":            supplements.ClientCache.GetLikeStatus(router.ClientID(c), page.FileName),
 						"ViewCount":        supplements.ClientCache.GetViewCount(page.FileName),
-						"Viewed":           supplements.ClientCache.GetViewStatus(c.IP(), page.FileName),
+						"Viewed":           supplements.ClientCache.GetViewStatus(router.ClientID(c), page.FileName),
+						"Reactions":        reactionTotals(supplements, lang, page.FileName),
 						"Medley":           page.Metadata.Medley,
 						"MedleyPart":       page.Metadata.MedleyPart,
 						"ToHighlight":      page.FileName == highlight,
+						"WordCount":        stats.Words,
+						"ReadingMinutes":   stats.Minutes(),
+						"ReadingTime":      stats.Duration,
 					})
 					break
 				}
@@ -131,6 +136,10 @@ func (r *BlogSearchHandler) Render(c *fiber.Ctx, supplements *router.Supplements
 			publishedTimeA, _ := time.Parse("2006-01-02 15:04:05 -07:00", a["PublishedTime"].(string))
 			publishedTimeB, _ := time.Parse("2006-01-02 15:04:05 -07:00", b["PublishedTime"].(string))
 			return publishedTimeB.Compare(publishedTimeA)
+		case "lengthAsc":
+			return cmp.Compare(a["ReadingTime"].(time.Duration), b["ReadingTime"].(time.Duration))
+		case "lengthDesc":
+			return cmp.Compare(b["ReadingTime"].(time.Duration), a["ReadingTime"].(time.Duration))
 		case "medley":
 			return cmp.Compare(a["MedleyPart"].(int), b["MedleyPart"].(int))
 		}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/SayaAndy/saya-today-web/internal/comments"
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ModerateCommentHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &ModerateCommentHandler{})
+}
+
+func (r *ModerateCommentHandler) Filter() (method string, path string) {
+	return "POST", "/api/v1/comments/moderate"
+}
+
+func (r *ModerateCommentHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *ModerateCommentHandler) TemplatesToInject() []string {
+	return []string{"views/partials/personal-page-status.html"}
+}
+
+func (r *ModerateCommentHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *ModerateCommentHandler) ToValidateLang() router.LangSetting {
+	return router.InReferer
+}
+
+func (r *ModerateCommentHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterMedium
+}
+
+func (r *ModerateCommentHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	isAuthor, err := supplements.Mailer.IsAuthor(router.ClientID(c))
+	if err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to check whether the client is a moderator: %w", err)
+	}
+	if !isAuthor {
+		return fiber.StatusForbidden, fmt.Errorf("client is not a moderator")
+	}
+
+	id, err := strconv.ParseInt(c.FormValue("comment_id"), 10, 64)
+	if err != nil {
+		return fiber.StatusBadRequest, fmt.Errorf("invalid comment_id value '%s'", c.FormValue("comment_id"))
+	}
+
+	var status comments.Status
+	var message string
+	switch c.FormValue("action") {
+	case "approve":
+		status, message = comments.Approved, "Approved"
+	case "reject":
+		status, message = comments.Rejected, "Rejected"
+	default:
+		return fiber.StatusBadRequest, fmt.Errorf("invalid action value '%s'", c.FormValue("action"))
+	}
+
+	templateMap["StatusId"] = fmt.Sprintf("comment-%d", id)
+	if err = supplements.Comments.Moderate(id, status); err != nil {
+		templateMap["Status"] = "Failed"
+		templateMap["Message"] = l10n.T.GetPath(lang, "Comments", "ModerationFailed").(string)
+		if !errors.Is(err, comments.ErrNotFound) {
+			slog.Error("failed to moderate a comment", slog.Int64("comment_id", id), slog.String("error", err.Error()))
+		}
+		return fiber.StatusUnprocessableEntity, nil
+	}
+
+	slog.Info("comment moderated", slog.Int64("comment_id", id), slog.String("status", string(status)))
+	templateMap["Status"] = "OK"
+	templateMap["Message"] = l10n.T.GetPath(lang, "Comments", message).(string)
+	return fiber.StatusOK, nil
+}
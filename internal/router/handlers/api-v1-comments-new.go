@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/SayaAndy/saya-today-web/internal/comments"
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/gofiber/fiber/v2"
+)
+
+type NewCommentHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &NewCommentHandler{})
+}
+
+func (r *NewCommentHandler) Filter() (method string, path string) {
+	return "POST", "/api/v1/comments/new"
+}
+
+func (r *NewCommentHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *NewCommentHandler) TemplatesToInject() []string {
+	return []string{"views/partials/personal-page-status.html"}
+}
+
+func (r *NewCommentHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *NewCommentHandler) ToValidateLang() router.LangSetting {
+	return router.InReferer
+}
+
+func (r *NewCommentHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterStrict
+}
+
+// Render saves the comment to the moderation queue, only clients with a
+// verified e-mail can comment so the author is able to reply to them
+func (r *NewCommentHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	codename, page, statusCode, err := blogPageFromReferer(c, supplements, lang)
+	if err != nil {
+		return statusCode, err
+	}
+
+	templateMap["StatusId"] = "comment-message"
+	templateMap["Status"] = "Failed"
+
+	email, _, err := supplements.Mailer.GetInfo(supplements.Mailer.GetHash(router.ClientID(c)))
+	if err != nil {
+		slog.Error("get info from mailer about a client", slog.String("error", err.Error()))
+	}
+	if email == "" {
+		templateMap["Message"] = l10n.T.GetPath(lang, "Comments", "NeedsEmail").(string)
+		return fiber.StatusUnprocessableEntity, nil
+	}
+
+	name := strings.TrimSpace(c.FormValue("name"))
+	body := strings.TrimSpace(c.FormValue("body"))
+	if name == "" || body == "" || utf8.RuneCountInString(name) > comments.MaxNameLength || utf8.RuneCountInString(body) > comments.MaxBodyLength {
+		templateMap["Message"] = l10n.T.GetPath(lang, "Comments", "Invalid").(string)
+		return fiber.StatusUnprocessableEntity, nil
+	}
+
+	var parentId int64
+	if rawParentId := c.FormValue("parent_id"); rawParentId != "" {
+		if parentId, err = strconv.ParseInt(rawParentId, 10, 64); err != nil {
+			templateMap["Message"] = l10n.T.GetPath(lang, "Comments", "Invalid").(string)
+			return fiber.StatusUnprocessableEntity, nil
+		}
+	}
+
+	// the comment is read again by the notification after the request is over,
+	// when the request buffers the strings point into are reused
+	comment := &comments.Comment{
+		Page:       strings.Clone(codename),
+		Lang:       strings.Clone(lang),
+		ParentId:   parentId,
+		Email:      email,
+		AuthorName: strings.Clone(name),
+		Body:       strings.Clone(body),
+	}
+	if _, err = supplements.Comments.Add(comment); err != nil {
+		if errors.Is(err, comments.ErrInvalidParent) {
+			templateMap["Message"] = l10n.T.GetPath(lang, "Comments", "Invalid").(string)
+			return fiber.StatusUnprocessableEntity, nil
+		}
+		templateMap["Message"] = l10n.T.GetPath(lang, "Comments", "SendingError").(string)
+		slog.Error("failed to save a comment", slog.String("page", codename), slog.String("error", err.Error()))
+		return fiber.StatusUnprocessableEntity, nil
+	}
+
+	go func() {
+		if err := supplements.Mailer.NotifyComment(comment, page.Metadata.Title); err != nil {
+			slog.Error("failed to notify about a new comment", slog.Int64("comment_id", comment.Id), slog.String("error", err.Error()))
+		}
+	}()
+
+	templateMap["Status"] = "OK"
+	templateMap["Message"] = l10n.T.GetPath(lang, "Comments", "Sent").(string)
+	return fiber.StatusOK, nil
+}
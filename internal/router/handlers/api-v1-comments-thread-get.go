@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/gofiber/fiber/v2"
+)
+
+type GetCommentsThreadHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &GetCommentsThreadHandler{})
+}
+
+func (r *GetCommentsThreadHandler) Filter() (method string, path string) {
+	return "GET", "/api/v1/comments/thread"
+}
+
+func (r *GetCommentsThreadHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *GetCommentsThreadHandler) TemplatesToInject() []string {
+	return []string{"views/partials/blog-page-comments.html"}
+}
+
+func (r *GetCommentsThreadHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *GetCommentsThreadHandler) ToValidateLang() router.LangSetting {
+	return router.InReferer
+}
+
+func (r *GetCommentsThreadHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	codename, _, statusCode, err := blogPageFromReferer(c, supplements, lang)
+	if err != nil {
+		return statusCode, err
+	}
+
+	thread, err := supplements.Comments.Thread(codename, lang)
+	if err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to get comments of the page: %w", err)
+	}
+
+	email, _, err := supplements.Mailer.GetInfo(supplements.Mailer.GetHash(router.ClientID(c)))
+	if err != nil {
+		slog.Error("get info from mailer about a client", slog.String("error", err.Error()))
+	}
+
+	templateMap["Comments"] = thread
+	templateMap["CanComment"] = email != ""
+	return fiber.StatusOK, nil
+}
@@ -39,7 +39,7 @@ func (r *OngoingVerificationHandler) ToValidateLang() router.LangSetting {
 }
 
 func (r *OngoingVerificationHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
-	isAllowed, whenAllowed, codeExpiry := supplements.Mailer.IsAllowedToRetryVerification(c.IP())
+	isAllowed, whenAllowed, codeExpiry := supplements.Mailer.IsAllowedToRetryVerification(router.ClientID(c))
 
 	sterileDataset := make(map[string]any)
 	sterileDataset["code-expiry-time"] = template.HTMLAttr(fmt.Sprintf("data-code-expiry-time=\"%d\"", codeExpiry.UnixMilli()))
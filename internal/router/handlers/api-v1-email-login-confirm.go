@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ConfirmLoginHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &ConfirmLoginHandler{})
+}
+
+func (r *ConfirmLoginHandler) Filter() (method string, path string) {
+	return "POST", "/api/v1/email/login/confirm"
+}
+
+func (r *ConfirmLoginHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *ConfirmLoginHandler) TemplatesToInject() []string {
+	return []string{"views/partials/personal-page-status.html"}
+}
+
+func (r *ConfirmLoginHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *ConfirmLoginHandler) ToValidateLang() router.LangSetting {
+	return router.InReferer
+}
+
+func (r *ConfirmLoginHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterStrict
+}
+
+func (r *ConfirmLoginHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	loginToken := c.FormValue("login_token")
+	templateMap["StatusId"] = "login-message"
+
+	// checked before the token, so a device without cookies doesn't spend it
+	// on a login that can't succeed
+	if !supplements.Identity.HasCookie(c) {
+		templateMap["Status"] = "Failed"
+		templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "LoginNeedsCookies").(string)
+		return fiber.StatusUnprocessableEntity, nil
+	}
+
+	address, err := supplements.Mailer.LoginTokenAddress(loginToken)
+	if err != nil {
+		slog.Warn("login token is invalid", slog.String("error", err.Error()))
+		templateMap["Status"] = "Failed"
+		templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "LoginFailed").(string)
+		return fiber.StatusUnprocessableEntity, nil
+	}
+
+	accountId, err := supplements.Mailer.Account(address)
+	if err != nil {
+		slog.Error("failed to get account by address", slog.String("error", err.Error()))
+		templateMap["Status"] = "Failed"
+		templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "LoginFailed").(string)
+		return fiber.StatusUnprocessableEntity, nil
+	}
+
+	if err = supplements.Identity.Login(c, accountId); err != nil {
+		templateMap["Status"] = "Failed"
+		if errors.Is(err, router.ErrNoIdentityCookie) {
+			templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "LoginNeedsCookies").(string)
+		} else {
+			slog.Error("failed to log in", slog.String("error", err.Error()))
+			templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "LoginFailed").(string)
+		}
+		return fiber.StatusUnprocessableEntity, nil
+	}
+	supplements.Mailer.ConsumeLoginToken(loginToken, address)
+
+	c.Set("HX-Refresh", "true")
+	templateMap["Status"] = "OK"
+	templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "LoginSuccess").(string)
+	return fiber.StatusOK, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"log/slog"
+
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SendLoginLinkHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &SendLoginLinkHandler{})
+}
+
+func (r *SendLoginLinkHandler) Filter() (method string, path string) {
+	return "POST", "/api/v1/email/login"
+}
+
+func (r *SendLoginLinkHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *SendLoginLinkHandler) TemplatesToInject() []string {
+	return []string{"views/partials/personal-page-status.html"}
+}
+
+func (r *SendLoginLinkHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *SendLoginLinkHandler) ToValidateLang() router.LangSetting {
+	return router.InReferer
+}
+
+func (r *SendLoginLinkHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterStrict
+}
+
+// Render answers the same way whether the address is known or not, so the
+// endpoint can't be used to find out who is subscribed
+func (r *SendLoginLinkHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	templateMap["StatusId"] = "login-message"
+
+	email := c.FormValue("email")
+	if email == "" {
+		templateMap["Status"] = "Failed"
+		templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "EmailEmpty").(string)
+		return fiber.StatusUnprocessableEntity, nil
+	}
+
+	if err = supplements.Mailer.SendLoginLink(email, lang); err != nil {
+		templateMap["Status"] = "Failed"
+		templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "LoginLinkSendingError").(string)
+		slog.Error("failed to send a login link", slog.String("error", err.Error()))
+		return fiber.StatusUnprocessableEntity, nil
+	}
+
+	templateMap["Status"] = "OK"
+	templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "LoginLinkSent").(string)
+	return fiber.StatusOK, nil
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"log/slog"
+
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/gofiber/fiber/v2"
+)
+
+type LogoutHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &LogoutHandler{})
+}
+
+func (r *LogoutHandler) Filter() (method string, path string) {
+	return "POST", "/api/v1/email/logout"
+}
+
+func (r *LogoutHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *LogoutHandler) TemplatesToInject() []string {
+	return []string{"views/partials/personal-page-status.html"}
+}
+
+func (r *LogoutHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *LogoutHandler) ToValidateLang() router.LangSetting {
+	return router.InReferer
+}
+
+func (r *LogoutHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterMedium
+}
+
+func (r *LogoutHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	templateMap["StatusId"] = "login-message"
+
+	if err = supplements.Identity.Logout(c); err != nil {
+		slog.Error("failed to log out", slog.String("error", err.Error()))
+		templateMap["Status"] = "Failed"
+		templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "LogoutFailed").(string)
+		return fiber.StatusUnprocessableEntity, nil
+	}
+
+	c.Set("HX-Refresh", "true")
+	templateMap["Status"] = "OK"
+	return fiber.StatusOK, nil
+}
@@ -44,7 +44,7 @@ func (r *SendVerificationCodeHandler) RateLimiter() *fiber.Handler {
 }
 
 func (r *SendVerificationCodeHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
-	id := c.IP()
+	id := router.ClientID(c)
 	templateMap["StatusId"] = "email-message"
 
 	email := c.FormValue("email")
@@ -51,13 +51,24 @@ func (r *VerifyCodeHandler) Render(c *fiber.Ctx, supplements *router.Supplements
 		return fiber.StatusUnprocessableEntity, nil
 	}
 
-	if err = supplements.Mailer.Verify(verificationCode, lang); err != nil {
+	userId, address, err := supplements.Mailer.Verify(verificationCode, lang)
+	if err != nil {
 		slog.Warn("verification code is invalid", slog.String("verification_code", verificationCode), slog.String("error", err.Error()))
 		templateMap["Status"] = "Failed"
 		templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "VerificationFailed").(string)
 		return fiber.StatusUnprocessableEntity, nil
 	}
 
+	// an anonymous device verifying an e-mail turns into an account, so the
+	// subscription can be recovered on other devices by logging in
+	if router.IsAnonymous(userId) {
+		if accountId, err := supplements.Mailer.Account(address); err != nil {
+			slog.Warn("failed to create an account for verified address", slog.String("error", err.Error()))
+		} else if err := supplements.Identity.LinkDevice(userId, accountId); err != nil {
+			slog.Warn("failed to link device to the account", slog.String("error", err.Error()))
+		}
+	}
+
 	templateMap["Status"] = "OK"
 	templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "VerificationSuccess").(string)
 	templateMap["DataAttributes"] = map[string]any{
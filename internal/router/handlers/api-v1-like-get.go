@@ -52,10 +52,10 @@ func (r *GetLikeHandler) Render(c *fiber.Ctx, supplements *router.Supplements, l
 		return fiber.StatusNotFound, fmt.Errorf("server did not find '%s' article", pageLink)
 	}
 
-	ip := c.IP()
-	likeStatus := supplements.ClientCache.GetLikeStatus(ip, page)
+	clientId := router.ClientID(c)
+	likeStatus := supplements.ClientCache.GetLikeStatus(clientId, page)
 
-	slog.Debug("someone requested the like status!", slog.String("ip", ip), slog.String("page", page), slog.Bool("like_status", likeStatus))
+	slog.Debug("someone requested the like status!", slog.String("client_id", clientId), slog.String("page", page), slog.Bool("like_status", likeStatus))
 	templateMap["Liked"] = likeStatus
 	templateMap["LikedCount"] = supplements.ClientCache.GetLikeCount(page)
 
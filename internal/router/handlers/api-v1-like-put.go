@@ -62,14 +62,14 @@ func (r *PutLikeHandler) Render(c *fiber.Ctx, supplements *router.Supplements, l
 		return fiber.StatusBadRequest, fmt.Errorf("invalid like value '%s'", c.FormValue("like"))
 	}
 
-	ip := c.IP()
+	clientId := router.ClientID(c)
 	if newLikeStatus {
-		supplements.ClientCache.LikeOn(ip, page)
+		supplements.ClientCache.LikeOn(clientId, page, lang)
 	} else {
-		supplements.ClientCache.LikeOff(ip, page)
+		supplements.ClientCache.LikeOff(clientId, page, lang)
 	}
 
-	slog.Debug("someone pressed the like button!", slog.String("ip", ip), slog.String("page", page), slog.String("new_like_status", fmt.Sprint(newLikeStatus)))
+	slog.Debug("someone pressed the like button!", slog.String("client_id", clientId), slog.String("page", page), slog.String("new_like_status", fmt.Sprint(newLikeStatus)))
 	templateMap["Liked"] = newLikeStatus
 	templateMap["LikedCount"] = supplements.ClientCache.GetLikeCount(page)
 	templateMap["StatusId"] = "email-message"
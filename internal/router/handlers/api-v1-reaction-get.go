@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/SayaAndy/saya-today-web/internal/blog"
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/gofiber/fiber/v2"
+)
+
+type GetReactionHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &GetReactionHandler{})
+}
+
+func (r *GetReactionHandler) Filter() (method string, path string) {
+	return "GET", "/api/v1/reaction"
+}
+
+func (r *GetReactionHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *GetReactionHandler) TemplatesToInject() []string {
+	return []string{"views/partials/blog-page-reactions.html"}
+}
+
+func (r *GetReactionHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *GetReactionHandler) ToValidateLang() router.LangSetting {
+	return router.InReferer
+}
+
+func (r *GetReactionHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	page, _, statusCode, err := blogPageFromReferer(c, supplements, lang)
+	if err != nil {
+		return statusCode, err
+	}
+
+	templateMap["Reactions"] = reactionButtons(supplements, lang, router.ClientID(c), page)
+	return fiber.StatusOK, nil
+}
+
+// blogPageFromReferer returns the codename and the metadata of the existing
+// blog page the request was sent from
+func blogPageFromReferer(c *fiber.Ctx, supplements *router.Supplements, lang string) (codename string, page *blog.Page, statusCode int, err error) {
+	path, pathParts, _, err := router.GetPathFromReferer(c)
+	if err != nil {
+		return "", nil, fiber.StatusBadRequest, fmt.Errorf("error getting path from referer: %w", err)
+	}
+
+	if len(pathParts) != 3 || pathParts[1] != "blog" {
+		return "", nil, fiber.StatusBadRequest, fmt.Errorf("invalid path format: expected '/:lang/blog/:page', got '%s'", path)
+	}
+
+	codename = pathParts[2]
+	pageLink := lang + "/" + codename + ".md"
+	pages, _ := supplements.BlogClient.Scan(pageLink)
+	if len(pages) == 0 {
+		return "", nil, fiber.StatusNotFound, fmt.Errorf("server did not find '%s' article", pageLink)
+	}
+	return codename, pages[0], fiber.StatusOK, nil
+}
+
+func availableReactions(supplements *router.Supplements, lang string) []string {
+	for _, availableLang := range supplements.AvailableLanguages {
+		if availableLang.Name == lang {
+			return availableLang.Reactions
+		}
+	}
+	return nil
+}
+
+// reactionButtons lists the reactions available in the language with their
+// counts on the page and whether the client put them
+func reactionButtons(supplements *router.Supplements, lang string, clientId string, page string) []fiber.Map {
+	counts := supplements.ClientCache.GetReactionCounts(page)
+	picked := supplements.ClientCache.GetReactions(clientId, page)
+
+	buttons := make([]fiber.Map, 0)
+	for _, reaction := range availableReactions(supplements, lang) {
+		buttons = append(buttons, fiber.Map{
+			"Emoji":  reaction,
+			"Count":  counts[reaction],
+			"Picked": picked[reaction],
+		})
+	}
+	return buttons
+}
+
+// reactionTotals lists the reactions available in the language that were put
+// on the page at least once
+func reactionTotals(supplements *router.Supplements, lang string, page string) []fiber.Map {
+	counts := supplements.ClientCache.GetReactionCounts(page)
+
+	totals := make([]fiber.Map, 0)
+	for _, reaction := range availableReactions(supplements, lang) {
+		if counts[reaction] > 0 {
+			totals = append(totals, fiber.Map{
+				"Emoji": reaction,
+				"Count": counts[reaction],
+			})
+		}
+	}
+	return totals
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PutReactionHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &PutReactionHandler{})
+}
+
+func (r *PutReactionHandler) Filter() (method string, path string) {
+	return "PUT", "/api/v1/reaction"
+}
+
+func (r *PutReactionHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *PutReactionHandler) TemplatesToInject() []string {
+	return []string{"views/partials/blog-page-reactions.html"}
+}
+
+func (r *PutReactionHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *PutReactionHandler) ToValidateLang() router.LangSetting {
+	return router.InReferer
+}
+
+func (r *PutReactionHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterMedium
+}
+
+func (r *PutReactionHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	page, _, statusCode, err := blogPageFromReferer(c, supplements, lang)
+	if err != nil {
+		return statusCode, err
+	}
+
+	reaction := c.FormValue("reaction")
+	if !slices.Contains(availableReactions(supplements, lang), reaction) {
+		return fiber.StatusBadRequest, fmt.Errorf("reaction '%s' is not available for '%s' lang", reaction, lang)
+	}
+
+	newStatus, err := strconv.ParseBool(c.FormValue("on", "true"))
+	if err != nil {
+		return fiber.StatusBadRequest, fmt.Errorf("invalid on value '%s'", c.FormValue("on"))
+	}
+
+	clientId := router.ClientID(c)
+	if newStatus {
+		supplements.ClientCache.ReactionOn(clientId, page, reaction)
+	} else {
+		supplements.ClientCache.ReactionOff(clientId, page, reaction)
+	}
+
+	slog.Debug("someone reacted to a page!", slog.String("client_id", clientId), slog.String("page", page), slog.String("reaction", reaction), slog.Bool("on", newStatus))
+	templateMap["Reactions"] = reactionButtons(supplements, lang, clientId, page)
+
+	return fiber.StatusOK, nil
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxDailyStatsDays = 365
+
+type GetDailyStatsHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &GetDailyStatsHandler{})
+}
+
+func (r *GetDailyStatsHandler) Filter() (method string, path string) {
+	return "GET", "/api/v1/stats/daily"
+}
+
+func (r *GetDailyStatsHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *GetDailyStatsHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *GetDailyStatsHandler) ToValidateLang() router.LangSetting {
+	return router.InForm
+}
+
+func (r *GetDailyStatsHandler) ContentType() string {
+	return fiber.MIMEApplicationJSONCharsetUTF8
+}
+
+func (r *GetDailyStatsHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterMedium
+}
+
+// Render returns the daily series of a single post if 'codename' is set, or
+// the sum over all posts of the language otherwise
+func (r *GetDailyStatsHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	days, err := strconv.Atoi(c.Query("days", "30"))
+	if err != nil || days < 1 || days > maxDailyStatsDays {
+		return fiber.StatusBadRequest, fmt.Errorf("'days' query parameter must be a number from 1 to %d", maxDailyStatsDays)
+	}
+
+	codename := c.Query("codename")
+	scanPrefix := lang + "/"
+	if codename != "" {
+		scanPrefix += codename + ".md"
+	}
+
+	pages, err := supplements.BlogClient.Scan(scanPrefix)
+	if err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to scan pages for '%s' lang: %w", lang, err)
+	}
+	if codename != "" && len(pages) == 0 {
+		return fiber.StatusNotFound, fmt.Errorf("server did not find '%s' article", scanPrefix)
+	}
+
+	codenames := make([]string, 0, len(pages))
+	for _, page := range pages {
+		codenames = append(codenames, page.FileName)
+	}
+
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, 1-days)
+	series, err := supplements.ClientCache.DailySeries(lang, codenames, from, to)
+	if err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to get daily stats: %w", err)
+	}
+
+	output, err := json.Marshal(map[string]any{
+		"lang":     lang,
+		"codename": codename,
+		"from":     from.Format(time.DateOnly),
+		"to":       to.Format(time.DateOnly),
+		"series":   series,
+	})
+	if err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to marshal daily stats: %w", err)
+	}
+	templateMap["Output"] = output
+
+	return fiber.StatusOK, nil
+}
@@ -58,7 +58,7 @@ func (r *PutSubsHandler) Render(c *fiber.Ctx, supplements *router.Supplements, l
 	}
 
 	specificTags := c.FormValue("tags_picked")
-	if err = supplements.Mailer.Subscribe(supplements.Mailer.GetHash(c.IP()), subscriptionTypeEnum, specificTags); err != nil {
+	if err = supplements.Mailer.Subscribe(supplements.Mailer.GetHash(router.ClientID(c)), subscriptionTypeEnum, specificTags); err != nil {
 		templateMap["Status"] = "Failed"
 		templateMap["Message"] = l10n.T.GetPath(lang, "UserProfile", "FailedToSubscribe").(string)
 		return fiber.StatusUnprocessableEntity, nil
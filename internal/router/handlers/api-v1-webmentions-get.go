@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/gofiber/fiber/v2"
+)
+
+type GetWebmentionsHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &GetWebmentionsHandler{})
+}
+
+func (r *GetWebmentionsHandler) Filter() (method string, path string) {
+	return "GET", "/api/v1/webmentions"
+}
+
+func (r *GetWebmentionsHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *GetWebmentionsHandler) TemplatesToInject() []string {
+	return []string{"views/partials/blog-page-webmentions.html"}
+}
+
+func (r *GetWebmentionsHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *GetWebmentionsHandler) ToValidateLang() router.LangSetting {
+	return router.InReferer
+}
+
+func (r *GetWebmentionsHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	codename, _, statusCode, err := blogPageFromReferer(c, supplements, lang)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if templateMap["Mentions"], err = supplements.Webmentions.Mentions(codename, lang); err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to get webmentions of the page: %w", err)
+	}
+	return fiber.StatusOK, nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/SayaAndy/saya-today-web/internal/activitypub"
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ActorHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &ActorHandler{})
+}
+
+func (r *ActorHandler) Filter() (method string, path string) {
+	return "GET", "/:lang/actor"
+}
+
+func (r *ActorHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *ActorHandler) ToCache() router.CacheSetting {
+	return router.ByUrlOnly
+}
+
+func (r *ActorHandler) ToValidateLang() router.LangSetting {
+	return router.InPath
+}
+
+func (r *ActorHandler) ContentType() string {
+	return activitypub.ContentType
+}
+
+func (r *ActorHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	actor, err := supplements.Federation.Actor(lang,
+		l10n.T.GetPath(lang, "ActivityPub", "Name").(string),
+		l10n.T.GetPath(lang, "ActivityPub", "Summary").(string))
+	if err != nil {
+		return fiber.StatusNotFound, err
+	}
+
+	if templateMap["Output"], err = json.Marshal(actor); err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to encode actor: %w", err)
+	}
+	return fiber.StatusOK, nil
+}
@@ -9,10 +9,14 @@ import (
 
 	"github.com/SayaAndy/saya-today-web/internal/blog"
 	"github.com/SayaAndy/saya-today-web/internal/frontmatter"
+	"github.com/SayaAndy/saya-today-web/internal/mdcontext"
+	"github.com/SayaAndy/saya-today-web/internal/readingtime"
 	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/internal/toc"
 	"github.com/SayaAndy/saya-today-web/l10n"
 	"github.com/gofiber/fiber/v2"
 	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
 )
 
 func init() {
@@ -76,14 +80,13 @@ func (r *BlogPageHandler) AddMeta(c *fiber.Ctx, supplements *router.Supplements,
 		title += " // " + l10n.T.GetPath(lang, "Medleys", metadata.Medley).(string)
 	}
 
-	return []router.MetaField{
+	meta = []router.MetaField{
 		{Property: "og:title", Content: title},
 		{Property: "og:description", Content: fmt.Sprintf("%s [%s]", metadata.ShortDescription, metadata.ActionDate)},
-		{Property: "og:image", Content: fmt.Sprintf(supplements.PhotoStorage.Thumbnail560p.BaseUrl, metadata.Thumbnail)},
 		{Property: "og:url", Content: fmt.Sprintf("%s/%s/blog/%s", templateMap["CanonicalEndpoint"], lang, c.Params("title"))},
 		{Property: "og:type", Content: "website"},
-		{Name: "twitter:card", Content: "summary_large_image"},
-	}, nil
+	}
+	return append(meta, shareCardMeta(templateMap, lang, "blog", c.Params("title"))...), nil
 }
 
 func (r *BlogPageHandler) AddLinkedData(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (ld map[string]any, err error) {
@@ -96,14 +99,20 @@ func (r *BlogPageHandler) AddLinkedData(c *fiber.Ctx, supplements *router.Supple
 		title += " // " + l10n.T.GetPath(lang, "Medleys", metadata.Medley).(string)
 	}
 
-	return map[string]any{
+	ld = map[string]any{
 		"@context":      "https://schema.org",
 		"@type":         "Article",
 		"headline":      title,
 		"description":   fmt.Sprintf("%s [%s]", metadata.ShortDescription, metadata.ActionDate),
 		"author":        map[string]string{"@type": "Person", "name": "Saya Andy"},
 		"datePublished": metadata.PublishedTime.UTC().Format(time.RFC3339),
-	}, nil
+	}
+	if stats, ok := supplements.ReadingTime.Get(lang, c.Params("title")); ok {
+		ld["wordCount"] = stats.Words
+		ld["timeRequired"] = stats.ISO8601()
+	}
+
+	return ld, nil
 }
 
 func (r *BlogPageHandler) RenderBody(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
@@ -113,7 +122,7 @@ func (r *BlogPageHandler) RenderBody(c *fiber.Ctx, supplements *router.Supplemen
 	}
 	title := pathParts[2]
 
-	metadata, parsedMarkdown, err := readBlogPost(supplements.MarkdownRenderer, supplements.BlogClient, lang+"/"+title)
+	metadata, parsedMarkdown, tocItems, stats, err := readBlogPost(supplements.MarkdownRenderer, supplements.BlogClient, lang, title)
 	if err != nil {
 		return fiber.StatusNotFound, fmt.Errorf("failed to find '%s' post: %w", title, err)
 	}
@@ -139,8 +148,12 @@ func (r *BlogPageHandler) RenderBody(c *fiber.Ctx, supplements *router.Supplemen
 	templateMap["ShortDescription"] = metadata.ShortDescription
 	templateMap["Thumbnail"] = metadata.Thumbnail
 	templateMap["Medley"] = metadata.Medley
+	templateMap["TOC"] = tocItems
+	templateMap["WordCount"] = stats.Words
+	templateMap["ReadingMinutes"] = stats.Minutes()
 
-	go supplements.ClientCache.View(c.IP(), title)
+	// both point into the request path, which is reused once the handler returns
+	go supplements.ClientCache.View(router.ClientID(c), strings.Clone(title), strings.Clone(lang))
 
 	return fiber.StatusOK, nil
 }
@@ -167,16 +180,23 @@ func (r *BlogPageHandler) RenderHeader(c *fiber.Ctx, supplements *router.Supplem
 	return fiber.StatusOK, nil
 }
 
-func readBlogPost(md goldmark.Markdown, blogClient blog.Client, sourceName string) (metadata *frontmatter.Metadata, html string, err error) {
-	metadata, markdown, err := blogClient.ReadFrontmatter(sourceName + ".md")
+func readBlogPost(md goldmark.Markdown, blogClient blog.Client, lang string, codename string) (metadata *frontmatter.Metadata, html string, tocItems []*toc.Item, stats readingtime.Stats, err error) {
+	metadata, markdown, err := blogClient.ReadFrontmatter(lang + "/" + codename + ".md")
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to read a frontmatter file: %w", err)
+		return nil, "", nil, readingtime.Stats{}, fmt.Errorf("failed to read a frontmatter file: %w", err)
+	}
+
+	pc := parser.NewContext()
+	mdcontext.SetLang(pc, lang)
+	mdcontext.SetCodename(pc, codename)
+	if metadata != nil && !toc.Enabled(metadata.ContentSettings) {
+		toc.Disable(pc)
 	}
 
 	var buf bytes.Buffer
-	if err := md.Convert(markdown, &buf); err != nil {
-		return nil, "", fmt.Errorf("convert source context from md to html: %w", err)
+	if err := md.Convert(markdown, &buf, parser.WithContext(pc)); err != nil {
+		return nil, "", nil, readingtime.Stats{}, fmt.Errorf("convert source context from md to html: %w", err)
 	}
 
-	return metadata, buf.String(), nil
+	return metadata, buf.String(), toc.Get(pc), readingtime.Get(pc), nil
 }
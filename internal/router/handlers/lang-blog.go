@@ -57,12 +57,13 @@ func (r *CatalogueHandler) SitemapInfo(supplements *router.Supplements) []router
 }
 
 func (r *CatalogueHandler) AddMeta(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (meta []router.MetaField, err error) {
-	return []router.MetaField{
+	meta = []router.MetaField{
 		{Property: "og:title", Content: l10n.T.GetPath(lang, "BlogSearch", "Header").(string)},
 		{Property: "og:description", Content: l10n.T.GetPath(lang, "BlogSearch", "Description").(string)},
 		{Property: "og:url", Content: fmt.Sprintf("%s/%s/blog", templateMap["CanonicalEndpoint"], lang)},
 		{Property: "og:type", Content: "website"},
-	}, nil
+	}
+	return append(meta, shareCardMeta(templateMap, lang, "page", "blog")...), nil
 }
 
 func (r *CatalogueHandler) RenderBody(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
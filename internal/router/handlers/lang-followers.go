@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/SayaAndy/saya-today-web/internal/activitypub"
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/gofiber/fiber/v2"
+)
+
+type FollowersHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &FollowersHandler{})
+}
+
+func (r *FollowersHandler) Filter() (method string, path string) {
+	return "GET", "/:lang/followers"
+}
+
+func (r *FollowersHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *FollowersHandler) ToCache() router.CacheSetting {
+	return router.ByUrlOnly
+}
+
+func (r *FollowersHandler) ToValidateLang() router.LangSetting {
+	return router.InPath
+}
+
+func (r *FollowersHandler) ContentType() string {
+	return activitypub.ContentType
+}
+
+func (r *FollowersHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	followers, err := supplements.Federation.Followers(lang)
+	if err != nil {
+		return fiber.StatusInternalServerError, err
+	}
+
+	if templateMap["Output"], err = json.Marshal(followers); err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to encode followers: %w", err)
+	}
+	return fiber.StatusOK, nil
+}
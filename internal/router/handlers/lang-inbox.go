@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/SayaAndy/saya-today-web/internal/activitypub"
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/gofiber/fiber/v2"
+)
+
+type InboxHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &InboxHandler{})
+}
+
+func (r *InboxHandler) Filter() (method string, path string) {
+	return "POST", "/:lang/inbox"
+}
+
+func (r *InboxHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *InboxHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *InboxHandler) ToValidateLang() router.LangSetting {
+	return router.InPath
+}
+
+func (r *InboxHandler) ContentType() string {
+	return fiber.MIMETextPlainCharsetUTF8
+}
+
+func (r *InboxHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterLoose
+}
+
+func (r *InboxHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	err = supplements.Federation.HandleInbox(lang, c.Method(), c.OriginalURL(), func(name string) string {
+		return c.Get(name)
+	}, c.Body())
+
+	switch {
+	case err == nil:
+	case errors.Is(err, activitypub.ErrUnsigned), errors.Is(err, activitypub.ErrInvalidSignature):
+		slog.Debug("rejected unverified activity", slog.String("lang", lang), slog.String("error", err.Error()))
+		return fiber.StatusUnauthorized, err
+	case errors.Is(err, activitypub.ErrBadActivity):
+		return fiber.StatusBadRequest, err
+	case errors.Is(err, activitypub.ErrNotFound):
+		return fiber.StatusNotFound, err
+	default:
+		return fiber.StatusInternalServerError, err
+	}
+
+	templateMap["Output"] = []byte("Accepted")
+	return fiber.StatusAccepted, nil
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/gofiber/fiber/v2"
+)
+
+func init() {
+	router.Routes = append(router.Routes, &ModerationHandler{})
+}
+
+type ModerationHandler struct {
+	router.BasicHandler
+}
+
+func (r *ModerationHandler) Filter() (method string, path string) {
+	return "GET", "/:lang/moderation"
+}
+
+func (r *ModerationHandler) IsTemplated() bool {
+	return true
+}
+
+func (r *ModerationHandler) TemplatesToInject() []string {
+	return []string{"views/pages/moderation-page.html"}
+}
+
+func (r *ModerationHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *ModerationHandler) ToValidateLang() router.LangSetting {
+	return router.InPath
+}
+
+func (r *ModerationHandler) AddMeta(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (meta []router.MetaField, err error) {
+	return []router.MetaField{
+		{Name: "robots", Content: "noindex,nofollow"},
+		{Property: "og:title", Content: l10n.T.GetPath(lang, "Comments", "ModerationHeader").(string)},
+		{Property: "og:description", Content: l10n.T.GetPath(lang, "Comments", "ModerationDescription").(string)},
+		{Property: "og:url", Content: fmt.Sprintf("%s/%s/moderation", templateMap["CanonicalEndpoint"], lang)},
+		{Property: "og:type", Content: "website"},
+	}, nil
+}
+
+func (r *ModerationHandler) RenderBody(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	templateMap["Title"] = l10n.T.GetPath(lang, "Comments", "ModerationHeader").(string)
+
+	isAuthor, err := supplements.Mailer.IsAuthor(router.ClientID(c))
+	if err != nil {
+		slog.Error("check whether a client is a moderator", slog.String("error", err.Error()))
+	}
+	templateMap["IsModerator"] = isAuthor
+	if !isAuthor {
+		return fiber.StatusOK, nil
+	}
+
+	if templateMap["Pending"], err = supplements.Comments.Pending(); err != nil {
+		return fiber.ErrInternalServerError.Code, fmt.Errorf("failed to get the moderation queue")
+	}
+	return fiber.StatusOK, nil
+}
+
+func (r *ModerationHandler) RenderHeader(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	templateMap["Title"] = l10n.T.GetPath(lang, "Comments", "ModerationHeader").(string)
+	return fiber.StatusOK, nil
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/SayaAndy/saya-today-web/internal/activitypub"
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OutboxHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &OutboxHandler{})
+}
+
+func (r *OutboxHandler) Filter() (method string, path string) {
+	return "GET", "/:lang/outbox"
+}
+
+func (r *OutboxHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *OutboxHandler) ToCache() router.CacheSetting {
+	return router.ByUrlOnly
+}
+
+func (r *OutboxHandler) ToValidateLang() router.LangSetting {
+	return router.InPath
+}
+
+func (r *OutboxHandler) ContentType() string {
+	return activitypub.ContentType
+}
+
+func (r *OutboxHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	posts, err := supplements.BlogClient.Scan(lang + "/")
+	if err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to scan blog pages: %w", err)
+	}
+
+	if templateMap["Output"], err = json.Marshal(supplements.Federation.Outbox(lang, posts)); err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to encode outbox: %w", err)
+	}
+	return fiber.StatusOK, nil
+}
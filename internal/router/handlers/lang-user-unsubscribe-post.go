@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"log/slog"
+
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/gofiber/fiber/v2"
+)
+
+type UnsubscribeConfirmHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &UnsubscribeConfirmHandler{})
+}
+
+func (r *UnsubscribeConfirmHandler) Filter() (method string, path string) {
+	return "POST", "/:lang/user/unsubscribe"
+}
+
+func (r *UnsubscribeConfirmHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *UnsubscribeConfirmHandler) TemplatesToInject() []string {
+	return []string{"views/pages/unsubscribe-page.html"}
+}
+
+func (r *UnsubscribeConfirmHandler) ToValidateLang() router.LangSetting {
+	return router.InPath
+}
+
+func (r *UnsubscribeConfirmHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterMedium
+}
+
+// Render cancels the subscription, either confirmed on the unsubscribe page or
+// requested by a mail client as the one-click unsubscribe of RFC 8058, which
+// is sent to the List-Unsubscribe link without cookies or referer
+func (r *UnsubscribeConfirmHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	unsubscribeCode := c.Query("code")
+	if unsubscribeCode == "" {
+		setUnsubscribeStatus(templateMap, "0, 0, 255", "(╭ರ_•́)", l10n.T.GetPath(lang, "UnsubscribePage", "UnsetCode").(string))
+		return fiber.StatusBadRequest, nil
+	}
+
+	clientError, serverError := supplements.Mailer.Unsubscribe(unsubscribeCode)
+	switch {
+	case clientError != nil:
+		slog.Info("got a client error when unsubscribing", slog.String("error", clientError.Error()))
+		setUnsubscribeStatus(templateMap, "255, 0, 0", "(͠≖~≖  ͡ )", l10n.T.GetPath(lang, "UnsubscribePage", "InvalidCode").(string))
+		return fiber.StatusBadRequest, nil
+	case serverError != nil:
+		slog.Error("got a server error when unsubscribing", slog.String("error", serverError.Error()))
+		setUnsubscribeStatus(templateMap, "255, 128, 0", "( ˶°ㅁ°) !!", l10n.T.GetPath(lang, "UnsubscribePage", "OnServerError").(string))
+		return fiber.StatusInternalServerError, nil
+	}
+
+	setUnsubscribeStatus(templateMap, "0, 255, 0", "♡⸜(˶˃ ᵕ ˂˶)⸝♡", l10n.T.GetPath(lang, "UnsubscribePage", "Success").(string))
+	return fiber.StatusOK, nil
+}
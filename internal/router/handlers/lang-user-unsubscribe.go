@@ -32,38 +32,27 @@ func (r *UnsubscribeHandler) ToValidateLang() router.LangSetting {
 	return router.InPath
 }
 
+// Render only asks to confirm, as links in letters get opened by scanners too,
+// the subscription is cancelled by the POST of the confirmation form
 func (r *UnsubscribeHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
-	var statusEmoji, statusText, statusColor string
-	var status int
-
-	unsubscribeCode := c.FormValue("code")
+	unsubscribeCode := c.Query("code")
 	if unsubscribeCode == "" {
-		statusColor = "0, 0, 255"
-		statusEmoji = "(╭ರ_•́)"
-		statusText = l10n.T.GetPath(lang, "UnsubscribePage", "UnsetCode").(string)
-		status = fiber.ErrBadRequest.Code
-	} else if clientError, serverError := supplements.Mailer.Unsubscribe(unsubscribeCode); clientError != nil {
-		slog.Info("got a client error when unsubscribing", slog.String("error", clientError.Error()))
-		statusColor = "255, 0, 0"
-		statusEmoji = "(͠≖~≖  ͡ )"
-		statusText = l10n.T.GetPath(lang, "UnsubscribePage", "InvalidCode").(string)
-		status = fiber.ErrBadRequest.Code
-	} else if serverError != nil {
-		slog.Error("got a server error when unsubscribing", slog.String("error", serverError.Error()))
-		statusColor = "255, 128, 0"
-		statusEmoji = "( ˶°ㅁ°) !!"
-		statusText = l10n.T.GetPath(lang, "UnsubscribePage", "OnServerError").(string)
-		status = fiber.ErrInternalServerError.Code
-	} else {
-		statusColor = "0, 255, 0"
-		statusEmoji = "♡⸜(˶˃ ᵕ ˂˶)⸝♡"
-		statusText = l10n.T.GetPath(lang, "UnsubscribePage", "Success").(string)
-		status = fiber.StatusOK
+		setUnsubscribeStatus(templateMap, "0, 0, 255", "(╭ರ_•́)", l10n.T.GetPath(lang, "UnsubscribePage", "UnsetCode").(string))
+		return fiber.StatusBadRequest, nil
+	}
+	if err := supplements.Mailer.VerifyUnsubscribeToken(unsubscribeCode); err != nil {
+		slog.Info("got a client error when confirming unsubscription", slog.String("error", err.Error()))
+		setUnsubscribeStatus(templateMap, "255, 0, 0", "(͠≖~≖  ͡ )", l10n.T.GetPath(lang, "UnsubscribePage", "InvalidCode").(string))
+		return fiber.StatusBadRequest, nil
 	}
 
-	templateMap["StatusColor"] = statusColor
-	templateMap["StatusEmoji"] = statusEmoji
-	templateMap["StatusText"] = statusText
+	setUnsubscribeStatus(templateMap, "255, 200, 0", "(・・ )?", l10n.T.GetPath(lang, "UnsubscribePage", "Confirm").(string))
+	templateMap["Code"] = unsubscribeCode
+	return fiber.StatusOK, nil
+}
 
-	return status, nil
+func setUnsubscribeStatus(templateMap fiber.Map, color string, emoji string, text string) {
+	templateMap["StatusColor"] = color
+	templateMap["StatusEmoji"] = emoji
+	templateMap["StatusText"] = text
 }
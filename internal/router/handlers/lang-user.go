@@ -39,19 +39,20 @@ func (r *UserHandler) ToValidateLang() router.LangSetting {
 }
 
 func (r *UserHandler) AddMeta(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (meta []router.MetaField, err error) {
-	return []router.MetaField{
+	meta = []router.MetaField{
 		{Name: "robots", Content: "noindex,nofollow"},
 		{Property: "og:title", Content: l10n.T.GetPath(lang, "UserProfile", "Header").(string)},
 		{Property: "og:description", Content: l10n.T.GetPath(lang, "UserProfile", "Description").(string)},
 		{Property: "og:url", Content: fmt.Sprintf("%s/%s/user", templateMap["CanonicalEndpoint"], lang)},
 		{Property: "og:type", Content: "website"},
-	}, nil
+	}
+	return append(meta, shareCardMeta(templateMap, lang, "page", "user")...), nil
 }
 
 func (r *UserHandler) RenderBody(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
 	templateMap["Title"] = l10n.T.GetPath(lang, "UserProfile", "Header").(string)
 
-	email, _, err := supplements.Mailer.GetInfo(supplements.Mailer.GetHash(c.IP()))
+	email, _, err := supplements.Mailer.GetInfo(supplements.Mailer.GetHash(router.ClientID(c)))
 	if err != nil {
 		slog.Error("get info from mailer about a client", slog.String("error", err.Error()))
 	}
@@ -61,7 +62,7 @@ func (r *UserHandler) RenderBody(c *fiber.Ctx, supplements *router.Supplements,
 		return fiber.ErrInternalServerError.Code, fmt.Errorf("failed to get the available tags")
 	}
 
-	subscriptionType, tags, err := supplements.Mailer.GetSubscriptions(c.IP())
+	subscriptionType, tags, err := supplements.Mailer.GetSubscriptions(router.ClientID(c))
 	if err != nil {
 		return fiber.ErrInternalServerError.Code, fmt.Errorf("failed to get the user subscriptions")
 	}
@@ -79,6 +80,8 @@ func (r *UserHandler) RenderBody(c *fiber.Ctx, supplements *router.Supplements,
 
 	templateMap["Email"] = email
 	templateMap["EmailCode"] = c.Query("email_code")
+	templateMap["LoginToken"] = c.Query("login_token")
+	templateMap["LoggedIn"] = mailer.IsAccount(router.ClientID(c))
 	templateMap["ExistingTags"] = tagsArray
 	return fiber.StatusOK, nil
 }
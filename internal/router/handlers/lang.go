@@ -51,16 +51,13 @@ func (r *HomeHandler) SitemapInfo(supplements *router.Supplements) []router.Site
 }
 
 func (r *HomeHandler) AddMeta(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (meta []router.MetaField, err error) {
-	return []router.MetaField{
+	meta = []router.MetaField{
 		{Property: "og:title", Content: l10n.T.GetPath(lang, "HomePage", "Header").(string)},
 		{Property: "og:description", Content: l10n.T.GetPath(lang, "HomePage", "HomePageDescription").(string)},
-		{Property: "og:image", Content: fmt.Sprintf(
-			supplements.PhotoStorage.HomePageGifs.BaseUrl,
-			supplements.PhotoStorage.HomePageGifs.Indexes[rand.Int()%len(supplements.PhotoStorage.HomePageGifs.Indexes)],
-		)},
 		{Property: "og:url", Content: fmt.Sprintf("%s/%s", templateMap["CanonicalEndpoint"], lang)},
 		{Property: "og:type", Content: "website"},
-	}, nil
+	}
+	return append(meta, shareCardMeta(templateMap, lang, "page", "home")...), nil
 }
 
 func (r *HomeHandler) RenderBody(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/config"
+	"github.com/SayaAndy/saya-today-web/internal/ogcard"
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ShareCardHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &ShareCardHandler{})
+}
+
+func (r *ShareCardHandler) Filter() (method string, path string) {
+	return "GET", "/og/:lang/:kind/:codename.png"
+}
+
+func (r *ShareCardHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *ShareCardHandler) ToCache() router.CacheSetting {
+	return router.ByUrlOnly
+}
+
+func (r *ShareCardHandler) CacheDuration() time.Duration {
+	return time.Hour
+}
+
+func (r *ShareCardHandler) ToValidateLang() router.LangSetting {
+	return router.NotRequired
+}
+
+func (r *ShareCardHandler) ContentType() string {
+	return "image/png"
+}
+
+func (r *ShareCardHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterMedium
+}
+
+func (r *ShareCardHandler) Render(c *fiber.Ctx, supplements *router.Supplements, _ string, templateMap fiber.Map) (statusCode int, err error) {
+	// the language follows the /og prefix, where the router doesn't look for it
+	lang := c.Params("lang")
+	if !slices.ContainsFunc(supplements.AvailableLanguages, func(l config.AvailableLanguageConfig) bool { return l.Name == lang }) {
+		return fiber.StatusNotFound, fmt.Errorf("unknown language '%s'", lang)
+	}
+
+	var card ogcard.Card
+
+	switch kind, codename := c.Params("kind"), c.Params("codename"); kind {
+	case "blog":
+		metadata, _, err := supplements.BlogClient.ReadFrontmatter(lang + "/" + codename + ".md")
+		if err != nil {
+			return fiber.StatusNotFound, fmt.Errorf("failed to find '%s' post: %w", codename, err)
+		}
+		card.Title = metadata.Title
+		card.Date = metadata.ActionDate
+		if metadata.Medley != "" {
+			card.Subtitle = l10n.T.GetPath(lang, "Medleys", metadata.Medley).(string)
+		}
+		if metadata.Thumbnail != "" {
+			card.Thumbnail = supplements.PhotoStorage.VariantUrl(800, metadata.Thumbnail)
+		}
+	case "page":
+		switch codename {
+		case "root":
+			card.Title = "Saya Blog"
+			card.Subtitle = "Choose Your Language"
+		case "home":
+			card.Title = l10n.T.GetPath(lang, "HomePage", "Header").(string)
+		case "blog":
+			card.Title = l10n.T.GetPath(lang, "BlogSearch", "Header").(string)
+			card.Subtitle = l10n.T.GetPath(lang, "BlogSearch", "Description").(string)
+		case "map":
+			card.Title = l10n.T.GetPath(lang, "GlobalMap", "Header").(string)
+			card.Subtitle = l10n.T.GetPath(lang, "GlobalMap", "Description").(string)
+		case "user":
+			card.Title = l10n.T.GetPath(lang, "UserProfile", "Header").(string)
+			card.Subtitle = l10n.T.GetPath(lang, "UserProfile", "Description").(string)
+		default:
+			return fiber.StatusNotFound, fmt.Errorf("unknown page '%s'", codename)
+		}
+	default:
+		return fiber.StatusNotFound, fmt.Errorf("unknown share card kind '%s'", kind)
+	}
+
+	content, err := supplements.ShareCards.Get(card)
+	if err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to generate share card: %w", err)
+	}
+
+	c.Set("Cache-Control", "public, max-age=86400")
+	templateMap["Output"] = content
+	return fiber.StatusOK, nil
+}
+
+// shareCardMeta returns the meta fields pointing crawlers to the share card
+// of the page
+func shareCardMeta(templateMap fiber.Map, lang string, kind string, codename string) []router.MetaField {
+	url := fmt.Sprintf("%s/og/%s/%s/%s.png", templateMap["CanonicalEndpoint"], lang, kind, codename)
+	return []router.MetaField{
+		{Property: "og:image", Content: url},
+		{Property: "og:image:type", Content: "image/png"},
+		{Property: "og:image:width", Content: strconv.Itoa(ogcard.Width)},
+		{Property: "og:image:height", Content: strconv.Itoa(ogcard.Height)},
+		{Name: "twitter:card", Content: "summary_large_image"},
+		{Name: "twitter:image", Content: url},
+	}
+}
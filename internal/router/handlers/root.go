@@ -47,6 +47,9 @@ func (r *RootHandler) AddMeta(c *fiber.Ctx, supplements *router.Supplements, lan
 		{Property: "og:url", Content: fmt.Sprint(templateMap["CanonicalEndpoint"]) + "/"},
 		{Property: "og:type", Content: "website"},
 	}
+	if len(supplements.AvailableLanguages) > 0 {
+		meta = append(meta, shareCardMeta(templateMap, supplements.AvailableLanguages[0].Name, "page", "root")...)
+	}
 	for _, field := range supplements.Meta {
 		meta = append(meta, router.MetaField{Name: field.Name, Content: field.Value})
 	}
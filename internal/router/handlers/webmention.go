@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/SayaAndy/saya-today-web/config"
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/SayaAndy/saya-today-web/internal/webmention"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebmentionHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &WebmentionHandler{})
+}
+
+func (r *WebmentionHandler) Filter() (method string, path string) {
+	return "POST", "/webmention"
+}
+
+func (r *WebmentionHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *WebmentionHandler) ToCache() router.CacheSetting {
+	return router.Disabled
+}
+
+func (r *WebmentionHandler) ToValidateLang() router.LangSetting {
+	return router.NotRequired
+}
+
+func (r *WebmentionHandler) ContentType() string {
+	return fiber.MIMETextPlainCharsetUTF8
+}
+
+func (r *WebmentionHandler) RateLimiter() *fiber.Handler {
+	return &router.RateLimiterMedium
+}
+
+// Render accepts a mention of a blog page, the source is verified later, so
+// the response only tells the request is well-formed
+func (r *WebmentionHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	source, target := c.FormValue("source"), c.FormValue("target")
+
+	sourceUrl, err := url.Parse(source)
+	if err != nil || (sourceUrl.Scheme != "http" && sourceUrl.Scheme != "https") || sourceUrl.Host == "" {
+		return fiber.StatusBadRequest, fmt.Errorf("source must be an absolute http(s) url")
+	}
+	if strings.TrimSuffix(source, "/") == strings.TrimSuffix(target, "/") {
+		return fiber.StatusBadRequest, fmt.Errorf("source and target must differ")
+	}
+
+	pagePath, ok := strings.CutPrefix(target, fmt.Sprintf("%s/", templateMap["CanonicalEndpoint"]))
+	if !ok {
+		return fiber.StatusBadRequest, fmt.Errorf("target is not on this site")
+	}
+	pagePath, _, _ = strings.Cut(pagePath, "#")
+	pathParts := strings.Split(strings.TrimSuffix(pagePath, "/"), "/")
+	if len(pathParts) != 3 || pathParts[1] != "blog" {
+		return fiber.StatusBadRequest, fmt.Errorf("target is not a blog page")
+	}
+
+	targetLang, codename := pathParts[0], pathParts[2]
+	if !slices.ContainsFunc(supplements.AvailableLanguages, func(l config.AvailableLanguageConfig) bool { return l.Name == targetLang }) {
+		return fiber.StatusBadRequest, fmt.Errorf("target is not a blog page")
+	}
+	if pages, _ := supplements.BlogClient.Scan(targetLang + "/" + codename + ".md"); len(pages) == 0 {
+		return fiber.StatusBadRequest, fmt.Errorf("target blog page does not exist")
+	}
+
+	// form values point into the request buffer, while the mention is verified
+	// after the response is sent
+	if err = supplements.Webmentions.Enqueue(strings.Clone(source), strings.Clone(target),
+		strings.Clone(codename), strings.Clone(targetLang)); err != nil {
+		if errors.Is(err, webmention.ErrQueueFull) {
+			return fiber.StatusServiceUnavailable, fmt.Errorf("too many webmentions are waiting for verification, try again later")
+		}
+		return fiber.StatusInternalServerError, err
+	}
+
+	slog.Debug("webmention accepted", slog.String("source", source), slog.String("target", target))
+	templateMap["Output"] = []byte("Accepted")
+	return fiber.StatusAccepted, nil
+}
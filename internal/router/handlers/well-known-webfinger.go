@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/SayaAndy/saya-today-web/internal/activitypub"
+	"github.com/SayaAndy/saya-today-web/internal/router"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebFingerHandler struct {
+	router.BasicHandler
+}
+
+func init() {
+	router.Routes = append(router.Routes, &WebFingerHandler{})
+}
+
+func (r *WebFingerHandler) Filter() (method string, path string) {
+	return "GET", "/.well-known/webfinger"
+}
+
+func (r *WebFingerHandler) IsTemplated() bool {
+	return false
+}
+
+func (r *WebFingerHandler) ToCache() router.CacheSetting {
+	return router.ByUrlAndQuery
+}
+
+func (r *WebFingerHandler) ToValidateLang() router.LangSetting {
+	return router.NotRequired
+}
+
+func (r *WebFingerHandler) ContentType() string {
+	return "application/jrd+json; charset=utf-8"
+}
+
+func (r *WebFingerHandler) Render(c *fiber.Ctx, supplements *router.Supplements, lang string, templateMap fiber.Map) (statusCode int, err error) {
+	jrd, err := supplements.Federation.WebFinger(c.Query("resource"))
+	if errors.Is(err, activitypub.ErrNotFound) {
+		return fiber.StatusNotFound, fmt.Errorf("resource '%s' is not found", c.Query("resource"))
+	}
+	if err != nil {
+		return fiber.StatusInternalServerError, err
+	}
+
+	c.Set(fiber.HeaderAccessControlAllowOrigin, "*")
+	if templateMap["Output"], err = json.Marshal(jrd); err != nil {
+		return fiber.StatusInternalServerError, fmt.Errorf("failed to encode webfinger document: %w", err)
+	}
+	return fiber.StatusOK, nil
+}
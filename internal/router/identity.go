@@ -0,0 +1,278 @@
+package router
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/internal/lru"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	identityCookieName = "sayauz_id"
+	identityLocalsKey  = "clientId"
+	deviceLocalsKey    = "deviceId"
+	identityMaxAge     = 400 * 24 * time.Hour
+	anonymousIdPrefix  = "anon:"
+)
+
+// ErrNoIdentityCookie is returned when logging in a client identified by its
+// IP address only
+var ErrNoIdentityCookie = errors.New("client has no identity cookie")
+
+// Identity issues a signed anonymous id cookie to every client and resolves the
+// client id handlers should key their data by. Clients without a valid cookie
+// are identified by their IP address, which keeps the data created before the
+// cookie existed reachable. The first time a cookie comes back, the data owned
+// by the IP of that request is migrated to the cookie id.
+//
+// The cookie doubles as a session: a device logged in an account is resolved
+// to the account id instead of its own one
+type Identity struct {
+	db           *sql.DB
+	secret       []byte
+	secureCookie bool
+	migrate      func(fromId string, toId string) error
+
+	seen *lru.Cache[string, struct{}]
+
+	// cookie id to the account id it is logged in, empty for anonymous devices
+	sessions *lru.Cache[string, string]
+}
+
+func NewIdentity(db *sql.DB, secret []byte, secureCookie bool, migrate func(fromId string, toId string) error) *Identity {
+	return &Identity{
+		db:           db,
+		secret:       secret,
+		secureCookie: secureCookie,
+		migrate:      migrate,
+		seen:         lru.New[string, struct{}](lru.ClientCapacity),
+		sessions:     lru.New[string, string](lru.ClientCapacity),
+	}
+}
+
+// ClientID returns the id of the client making the request, either
+// "anon:<cookie id>" or the raw IP address as a fallback
+func ClientID(c *fiber.Ctx) string {
+	if id, ok := c.Locals(identityLocalsKey).(string); ok {
+		return id
+	}
+	return strings.Clone(c.IP())
+}
+
+// IsAnonymous reports whether the client id belongs to a device identified by
+// its cookie rather than by its IP address or an account
+func IsAnonymous(clientId string) bool {
+	return strings.HasPrefix(clientId, anonymousIdPrefix)
+}
+
+func (i *Identity) Handler(c *fiber.Ctx) error {
+	id, ok := i.verify(c.Cookies(identityCookieName))
+	if !ok {
+		if _, err := i.issue(c); err != nil {
+			slog.Warn("failed to issue identity cookie", slog.String("error", err.Error()))
+		}
+		ip := strings.Clone(c.IP())
+		c.Locals(deviceLocalsKey, ip)
+		c.Locals(identityLocalsKey, ip)
+		return c.Next()
+	}
+
+	deviceId := anonymousIdPrefix + id
+	c.Locals(deviceLocalsKey, deviceId)
+	c.Locals(identityLocalsKey, deviceId)
+
+	if err := i.migrateOnce(id, strings.Clone(c.IP()), deviceId); err != nil {
+		slog.Warn("failed to migrate client data to the identity cookie", slog.String("error", err.Error()))
+	}
+
+	accountId, err := i.session(id)
+	if err != nil {
+		slog.Warn("failed to look up the client session", slog.String("error", err.Error()))
+	} else if accountId != "" {
+		c.Locals(identityLocalsKey, accountId)
+	}
+	return c.Next()
+}
+
+func (i *Identity) issue(c *fiber.Ctx) (id string, err error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", fmt.Errorf("generate id: %w", err)
+	}
+	id = base64.RawURLEncoding.EncodeToString(idBytes)
+
+	c.Cookie(&fiber.Cookie{
+		Name:     identityCookieName,
+		Value:    id + "." + i.sign("cookie", id),
+		Path:     "/",
+		MaxAge:   int(identityMaxAge.Seconds()),
+		Secure:   i.secureCookie,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return id, nil
+}
+
+func (i *Identity) verify(value string) (id string, ok bool) {
+	id, signature, found := strings.Cut(value, ".")
+	if !found || id == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signature), []byte(i.sign("cookie", id))) {
+		return "", false
+	}
+	// the cookie value points into the request buffer, which is reused
+	return strings.Clone(id), true
+}
+
+func (i *Identity) sign(purpose string, id string) string {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(purpose + "." + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// migrateOnce moves the data of ip to clientId unless the cookie id was seen
+// before, by this or any previous run
+func (i *Identity) migrateOnce(id string, ip string, clientId string) error {
+	if firstTime, err := i.register(id); err != nil || !firstTime {
+		return err
+	}
+
+	if err := i.migrate(ip, clientId); err != nil {
+		return fmt.Errorf("fail to migrate client data: %w", err)
+	}
+	slog.Debug("migrated client data to identity cookie")
+	return nil
+}
+
+// register remembers the cookie id, firstTime is true only for the first
+// call ever made with the id
+func (i *Identity) register(id string) (firstTime bool, err error) {
+	if _, seen := i.seen.Get(id); seen {
+		return false, nil
+	}
+	i.seen.Add(id, struct{}{})
+
+	result, err := i.db.Exec(`INSERT OR IGNORE INTO client_identity_table(client_hash, first_seen) VALUES(?, ?);`,
+		i.sign("seen", id), time.Now().Unix())
+	if err != nil {
+		i.seen.Remove(id)
+		return false, fmt.Errorf("fail to register client identity: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+func (i *Identity) session(id string) (accountId string, err error) {
+	if accountId, ok := i.sessions.Get(id); ok {
+		return accountId, nil
+	}
+
+	err = i.db.QueryRow(`SELECT account_id FROM session_table WHERE client_hash=? AND created_at>? LIMIT 1;`,
+		i.sign("session", id), time.Now().Add(-identityMaxAge).Unix()).Scan(&accountId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("fail to query session: %w", err)
+	}
+
+	i.sessions.Add(id, accountId)
+	return accountId, nil
+}
+
+func (i *Identity) saveSession(id string, accountId string) error {
+	if _, err := i.db.Exec(`INSERT INTO session_table(client_hash, account_id, created_at) VALUES(?, ?, ?)
+  ON CONFLICT(client_hash) DO UPDATE SET
+  	account_id=excluded.account_id,
+	created_at=excluded.created_at;`, i.sign("session", id), accountId, time.Now().Unix()); err != nil {
+		return fmt.Errorf("fail to save session: %w", err)
+	}
+	i.sessions.Add(id, accountId)
+	return nil
+}
+
+func (i *Identity) deleteSession(id string) error {
+	if _, err := i.db.Exec(`DELETE FROM session_table WHERE client_hash=?;`, i.sign("session", id)); err != nil {
+		return fmt.Errorf("fail to delete session: %w", err)
+	}
+	i.sessions.Add(id, "")
+	return nil
+}
+
+// HasCookie tells if the requesting device sent a valid identity cookie, only
+// such devices can log in
+func (i *Identity) HasCookie(c *fiber.Ctx) bool {
+	deviceId, _ := c.Locals(deviceLocalsKey).(string)
+	return strings.HasPrefix(deviceId, anonymousIdPrefix)
+}
+
+// Login logs the requesting device in the account. The data of an anonymous
+// device is moved into the account, and the device gets a fresh cookie id so a
+// cookie leaked before logging in can't be used to access the account
+func (i *Identity) Login(c *fiber.Ctx, accountId string) error {
+	deviceId, _ := c.Locals(deviceLocalsKey).(string)
+	oldId, ok := strings.CutPrefix(deviceId, anonymousIdPrefix)
+	if !ok {
+		return ErrNoIdentityCookie
+	}
+
+	if ClientID(c) == deviceId {
+		if err := i.migrate(deviceId, accountId); err != nil {
+			return fmt.Errorf("fail to move device data to the account: %w", err)
+		}
+	}
+
+	newId, err := i.issue(c)
+	if err != nil {
+		return fmt.Errorf("fail to issue a new identity cookie: %w", err)
+	}
+	if _, err := i.register(newId); err != nil {
+		return err
+	}
+	if err := i.saveSession(newId, accountId); err != nil {
+		return err
+	}
+	if err := i.deleteSession(oldId); err != nil {
+		slog.Warn("failed to delete the session of a replaced identity cookie", slog.String("error", err.Error()))
+	}
+
+	c.Locals(deviceLocalsKey, anonymousIdPrefix+newId)
+	c.Locals(identityLocalsKey, accountId)
+	return nil
+}
+
+// LinkDevice logs a device other than the requesting one in the account,
+// moving its data into the account. IP identified devices are left as is
+func (i *Identity) LinkDevice(deviceId string, accountId string) error {
+	id, ok := strings.CutPrefix(deviceId, anonymousIdPrefix)
+	if !ok {
+		return ErrNoIdentityCookie
+	}
+
+	if err := i.migrate(deviceId, accountId); err != nil {
+		return fmt.Errorf("fail to move device data to the account: %w", err)
+	}
+	return i.saveSession(id, accountId)
+}
+
+// Logout returns the requesting device to its anonymous identity
+func (i *Identity) Logout(c *fiber.Ctx) error {
+	deviceId, _ := c.Locals(deviceLocalsKey).(string)
+	id, ok := strings.CutPrefix(deviceId, anonymousIdPrefix)
+	if !ok {
+		return ErrNoIdentityCookie
+	}
+
+	if err := i.deleteSession(id); err != nil {
+		return err
+	}
+	c.Locals(identityLocalsKey, deviceId)
+	return nil
+}
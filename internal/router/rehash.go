@@ -0,0 +1,63 @@
+package router
+
+import (
+	"log/slog"
+
+	"github.com/SayaAndy/saya-today-web/internal/clientcache"
+	"github.com/SayaAndy/saya-today-web/internal/mailer"
+)
+
+const rehashQueueSize = 256
+
+// Rehasher moves the data clients left under previous salts to their current
+// hashes. Every previous salt costs another argon2 hash of the id, so it is done
+// in the background and a client may see its old data only after a request or two
+type Rehasher struct {
+	clientCache *clientcache.ClientCache
+	mailer      *mailer.Mailer
+	queue       chan string
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+func NewRehasher(clientCache *clientcache.ClientCache, m *mailer.Mailer) *Rehasher {
+	r := &Rehasher{
+		clientCache: clientCache,
+		mailer:      m,
+		queue:       make(chan string, rehashQueueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Enqueue schedules the client id to be rehashed. Ids are dropped while the
+// queue is full, they are queued again with the next request of the client
+func (r *Rehasher) Enqueue(id string) {
+	select {
+	case r.queue <- id:
+	default:
+	}
+}
+
+// Close stops the worker, ids left in the queue are not rehashed
+func (r *Rehasher) Close() {
+	close(r.stop)
+	<-r.done
+}
+
+func (r *Rehasher) run() {
+	defer close(r.done)
+	for {
+		select {
+		case <-r.stop:
+			return
+		case id := <-r.queue:
+			r.clientCache.Rehash(id)
+			if err := r.mailer.Rehash(id); err != nil {
+				slog.Warn("failed to move mail settings to the current salt", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
@@ -16,13 +16,26 @@ import (
 	"time"
 
 	"github.com/SayaAndy/saya-today-web/config"
+	"github.com/SayaAndy/saya-today-web/internal/activitypub"
+	"github.com/SayaAndy/saya-today-web/internal/admonition"
 	"github.com/SayaAndy/saya-today-web/internal/blog"
 	"github.com/SayaAndy/saya-today-web/internal/blogtrigger"
+	"github.com/SayaAndy/saya-today-web/internal/clientcache"
+	"github.com/SayaAndy/saya-today-web/internal/comments"
+	"github.com/SayaAndy/saya-today-web/internal/corpus"
 	"github.com/SayaAndy/saya-today-web/internal/factgiver"
 	"github.com/SayaAndy/saya-today-web/internal/glightbox"
+	"github.com/SayaAndy/saya-today-web/internal/linkgraph"
 	"github.com/SayaAndy/saya-today-web/internal/mailer"
+	"github.com/SayaAndy/saya-today-web/internal/mapblock"
+	"github.com/SayaAndy/saya-today-web/internal/media"
+	"github.com/SayaAndy/saya-today-web/internal/ogcard"
+	"github.com/SayaAndy/saya-today-web/internal/readingtime"
 	"github.com/SayaAndy/saya-today-web/internal/tailwind"
 	"github.com/SayaAndy/saya-today-web/internal/templatemanager"
+	"github.com/SayaAndy/saya-today-web/internal/toc"
+	"github.com/SayaAndy/saya-today-web/internal/webmention"
+	"github.com/SayaAndy/saya-today-web/internal/wikilink"
 	"github.com/dgraph-io/ristretto/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
@@ -31,6 +44,7 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/renderer/html"
@@ -95,13 +109,22 @@ type Supplements struct {
 	DB                 *sql.DB
 	BlogClient         blog.Client
 	AvailableLanguages []config.AvailableLanguageConfig
-	ClientCache        *ClientCache
+	ClientCache        *clientcache.ClientCache
 	PageCache          *ristretto.Cache[string, []byte]
 	FactGiver          *factgiver.FactGiver
 	Mailer             *mailer.Mailer
 	BlogTrigger        *blogtrigger.BlogTriggerScheduler
 	TemplateManager    *templatemanager.TemplateManager
 	MarkdownRenderer   goldmark.Markdown
+	Corpus             *corpus.Corpus
+	LinkGraph          *linkgraph.Graph
+	ReadingTime        *readingtime.Index
+	ShareCards         *ogcard.Generator
+	Identity           *Identity
+	Rehasher           *Rehasher
+	Comments           *comments.Store
+	Webmentions        *webmention.Receiver
+	Federation         *activitypub.Federation
 	Meta               []config.MetaConfig
 	PhotoStorage       config.PhotoStorageConfig
 	StaticStorage      config.StaticStorageConfig
@@ -127,6 +150,9 @@ func NewRouter(cfg *config.Config) (*Router, error) {
 	if err != nil {
 		return nil, fmt.Errorf("fail to initialize db: %w", err)
 	}
+	// the db is opened in exclusive locking mode, a second connection would
+	// wait for the lock of the first one and fail with SQLITE_BUSY
+	supplements.DB.SetMaxOpenConns(1)
 
 	driver, err := sqlite3.WithInstance(supplements.DB, &sqlite3.Config{})
 	if err != nil {
@@ -152,7 +178,18 @@ func NewRouter(cfg *config.Config) (*Router, error) {
 
 	supplements.MarkdownRenderer = goldmark.New(
 		goldmark.WithExtensions(
-			glightbox.NewGLightboxExtension(cfg.PhotoStorage),
+			glightbox.NewGLightboxExtension(cfg.PhotoStorage, 5*time.Minute),
+			media.NewMediaExtension(cfg.PhotoStorage, cfg.StaticStorage.Media),
+			mapblock.NewMapExtension(cfg.StaticStorage.Map, supplements.BlogClient.ReadAll),
+			admonition.NewAdmonitionExtension(),
+			wikilink.NewWikiLinkExtension(wikilink.NewCatalog(supplements.BlogClient, 5*time.Minute)),
+			extension.Footnote,
+			extension.Table,
+			extension.Strikethrough,
+			extension.TaskList,
+			toc.NewTOCExtension(),
+			linkgraph.NewLinkGraphExtension(),
+			readingtime.NewReadingTimeExtension(),
 			tailwind.NewTailwindExtension(),
 		),
 		goldmark.WithParserOptions(
@@ -163,13 +200,24 @@ func NewRouter(cfg *config.Config) (*Router, error) {
 			renderer.NewRenderer(
 				renderer.WithNodeRenderers(
 					util.Prioritized(tailwind.NewCustomLinkRenderer(html.WithUnsafe(), html.WithXHTML()), 50),
+					util.Prioritized(tailwind.NewCustomFootnoteRenderer(html.WithXHTML()), 50),
+					util.Prioritized(tailwind.NewCodeBlockRenderer(html.WithXHTML()), 50),
 					util.Prioritized(html.NewRenderer(html.WithXHTML()), 100),
 				),
 			),
 		),
 	)
 
-	supplements.ClientCache, err = NewClientCache(supplements.DB, []byte(cfg.Auth.Salt))
+	supplements.Corpus = corpus.NewCorpus(supplements.BlogClient, supplements.MarkdownRenderer, 15*time.Minute)
+	supplements.LinkGraph = linkgraph.NewGraph(supplements.Corpus)
+	supplements.ReadingTime = readingtime.NewIndex(supplements.Corpus)
+
+	supplements.ShareCards, err = ogcard.NewGenerator(cfg.ShareCard)
+	if err != nil {
+		return nil, fmt.Errorf("fail to initialize share card generator: %w", err)
+	}
+
+	supplements.ClientCache, err = clientcache.NewClientCache(supplements.DB, []byte(cfg.Auth.Salt), saltsToBytes(cfg.Auth.PreviousSalts), 30*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("fail to initialize client cache: %w", err)
 	}
@@ -188,14 +236,51 @@ func NewRouter(cfg *config.Config) (*Router, error) {
 		return nil, fmt.Errorf("fail to initialize fact giver: %w", err)
 	}
 
-	supplements.Mailer, err = mailer.NewMailer(supplements.DB, cfg.Mail.ClientHost, cfg.Mail.MailHost,
-		cfg.Mail.PublicName, cfg.Mail.MailAddress, cfg.Mail.Username, cfg.Mail.Password, []byte(cfg.Mail.Salt))
+	mailTransport, err := mailer.NewTransport(cfg.Mail.Transport)
+	if err != nil {
+		return nil, fmt.Errorf("fail to initialize mail transport: %w", err)
+	}
+
+	supplements.Mailer, err = mailer.NewMailer(supplements.DB, cfg.Mail.ClientHost, cfg.Mail.PublicName, cfg.Mail.MailAddress,
+		cfg.Mail.AuthorAddress, []byte(cfg.Mail.Salt), saltsToBytes(cfg.Mail.PreviousSalts),
+		[]byte(cfg.Mail.UnsubscribeSecret), cfg.PhotoStorage, mailTransport, cfg.Mail.SendPerMinute)
 	if err != nil {
 		return nil, fmt.Errorf("fail to initialize mailer: %w", err)
 	}
 
+	supplements.Comments = comments.NewStore(supplements.DB)
+
+	outboundClient := webmention.NewClient(cfg.Webmention.AllowPrivateNetworks)
+	supplements.Webmentions = webmention.NewReceiver(supplements.DB, outboundClient)
+	webmentionSender := webmention.NewSender(outboundClient)
+
+	langs := make([]string, 0, len(cfg.AvailableLanguages))
+	for _, lang := range cfg.AvailableLanguages {
+		langs = append(langs, lang.Name)
+	}
+	supplements.Federation, err = activitypub.NewFederation(supplements.DB, outboundClient, cfg.CanonicalEndpoint, langs, cfg.PhotoStorage)
+	if err != nil {
+		return nil, fmt.Errorf("fail to initialize activitypub federation: %w", err)
+	}
+
+	supplements.Rehasher = NewRehasher(supplements.ClientCache, supplements.Mailer)
+
+	supplements.Identity = NewIdentity(supplements.DB, []byte(cfg.Auth.IdentitySecret), strings.HasPrefix(cfg.CanonicalEndpoint, "https://"),
+		func(fromId string, toId string) error {
+			supplements.ClientCache.Migrate(fromId, toId)
+			return supplements.Mailer.Migrate(fromId, toId)
+		})
+
 	supplements.BlogTrigger, err = blogtrigger.NewBlogTriggerScheduler(supplements.BlogClient, cfg.AvailableLanguages, cfg.Mail.Trigger.OnNewPost,
 		func(bp []*blog.Page) error {
+			go sendWebmentions(supplements, webmentionSender, cfg.CanonicalEndpoint, bp)
+			go func() {
+				for _, post := range bp {
+					if err := supplements.Federation.Deliver(post); err != nil {
+						slog.Warn("failed to deliver new post to some activitypub followers", slog.String("codename", post.FileName), slog.String("error", err.Error()))
+					}
+				}
+			}()
 			for _, post := range bp {
 				if err := supplements.Mailer.NewPost(post); err != nil {
 					return err
@@ -238,6 +323,13 @@ func NewRouter(cfg *config.Config) (*Router, error) {
 
 	app.Use(etag.New())
 
+	app.Use(supplements.Identity.Handler)
+
+	app.Use(func(c *fiber.Ctx) error {
+		supplements.Rehasher.Enqueue(ClientID(c))
+		return c.Next()
+	})
+
 	app.Use(func(c *fiber.Ctx) error {
 		path := c.Path()
 		if len(path) > 1 && path[len(path)-1] == '/' {
@@ -419,6 +511,12 @@ func (r *Router) Close() (err error) {
 	if err = r.app.Shutdown(); err != nil {
 		allErrors = append(allErrors, fmt.Errorf("fail to shutdown fiber server: %w", err))
 	}
+	slog.Debug("stopping rehashing of client ids")
+	r.supplements.Rehasher.Close()
+	slog.Debug("finishing webmention verification")
+	r.supplements.Webmentions.Close()
+	slog.Debug("stopping mail outbox")
+	r.supplements.Mailer.Close()
 	slog.Debug("dumping cache")
 	if err = r.supplements.ClientCache.Close(); err != nil {
 		allErrors = append(allErrors, fmt.Errorf("fail to dump cache: %w", err))
@@ -447,11 +545,15 @@ func (r *Router) generalPage(c *fiber.Ctx, route Route, lang string) error {
 		cacheKey = fmt.Sprintf("%s.general-page.%s", method, trimmedPath)
 	case ByUrlAndQuery:
 		cacheKey = fmt.Sprintf("%s.general-page.%s.%s", method, trimmedPath, queryString)
+	case Disabled:
+		c.Set("Cache-Control", "no-store, no-cache, must-revalidate")
 	}
 
-	if val, ok := r.supplements.PageCache.Get(cacheKey); val != nil && ok {
-		c.Set(fiber.HeaderContentType, route.ContentType())
-		return c.Status(fiber.StatusOK).Send(val)
+	if route.ToCache() != Disabled {
+		if val, ok := r.supplements.PageCache.Get(cacheKey); val != nil && ok {
+			c.Set(fiber.HeaderContentType, route.ContentType())
+			return c.Status(fiber.StatusOK).Send(val)
+		}
 	}
 
 	valueMap := fiber.Map{
@@ -525,7 +627,9 @@ func (r *Router) generalPage(c *fiber.Ctx, route Route, lang string) error {
 		return c.Status(fiber.ErrInternalServerError.Code).SendString("failed to generate full page")
 	}
 
-	go r.supplements.PageCache.SetWithTTL(cacheKey, content, int64(len(content)), route.CacheDuration())
+	if route.ToCache() != Disabled {
+		go r.supplements.PageCache.SetWithTTL(cacheKey, content, int64(len(content)), route.CacheDuration())
+	}
 	c.Set(fiber.HeaderContentType, route.ContentType())
 	return c.Status(fiber.StatusOK).Send(content)
 }
@@ -697,3 +801,36 @@ func GetPathFromReferer(c *fiber.Ctx) (path string, pathParts []string, queryStr
 	queryString = urlStruct.RawQuery
 	return
 }
+
+func saltsToBytes(salts []string) [][]byte {
+	result := make([][]byte, 0, len(salts))
+	for _, salt := range salts {
+		result = append(result, []byte(salt))
+	}
+	return result
+}
+
+// sendWebmentions notifies the sites new posts link to
+func sendWebmentions(supplements *Supplements, sender *webmention.Sender, canonicalEndpoint string, posts []*blog.Page) {
+	canonicalUrl, err := url.Parse(canonicalEndpoint)
+	if err != nil {
+		slog.Error("failed to parse canonical endpoint for sending webmentions", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, post := range posts {
+		_, markdown, err := supplements.BlogClient.ReadFrontmatter(post.Lang + "/" + post.FileName + ".md")
+		if err != nil {
+			slog.Error("failed to read new post for sending webmentions", slog.String("lang", post.Lang), slog.String("codename", post.FileName), slog.String("error", err.Error()))
+			continue
+		}
+
+		source := fmt.Sprintf("%s/%s/blog/%s", canonicalEndpoint, post.Lang, post.FileName)
+		targets := webmention.ExternalLinks(supplements.MarkdownRenderer, markdown, post.Lang, post.FileName, canonicalUrl.Host)
+		sent, err := sender.Send(source, targets)
+		if err != nil {
+			slog.Warn("failed to send some webmentions", slog.String("source", source), slog.String("error", err.Error()))
+		}
+		slog.Debug("sent webmentions for a new post", slog.String("source", source), slog.Int("targets", len(targets)), slog.Int("sent", sent))
+	}
+}
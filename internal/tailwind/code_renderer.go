@@ -0,0 +1,189 @@
+package tailwind
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// tokenClasses maps chroma token types onto theme colors, so highlighted code
+// follows the palette switch just like the rest of the page.
+var tokenClasses = map[chroma.TokenType]string{
+	chroma.Keyword:             "text-accent-deep font-bold",
+	chroma.KeywordConstant:     "text-accent-deep italic",
+	chroma.KeywordType:         "text-accent-deep",
+	chroma.Name:                "text-main-hard",
+	chroma.NameBuiltin:         "text-accent-deep italic",
+	chroma.NameFunction:        "text-main-hard font-bold",
+	chroma.NameClass:           "text-main-hard font-bold underline decoration-dotted",
+	chroma.NameTag:             "text-accent-deep",
+	chroma.NameAttribute:       "text-main-medium italic",
+	chroma.NameDecorator:       "text-main-medium italic",
+	chroma.Literal:             "text-secondary",
+	chroma.LiteralString:       "text-secondary",
+	chroma.LiteralStringEscape: "text-accent-deep",
+	chroma.LiteralNumber:       "text-main-medium",
+	chroma.Operator:            "text-main-medium",
+	chroma.Punctuation:         "text-main-soft",
+	chroma.Comment:             "text-main-soft italic font-thin",
+	chroma.CommentPreproc:      "text-main-medium italic",
+	chroma.GenericInserted:     "bg-accent-light/40",
+	chroma.GenericDeleted:      "line-through text-main-soft",
+	chroma.GenericHeading:      "font-bold",
+	chroma.GenericEmph:         "italic",
+	chroma.GenericStrong:       "font-bold",
+	chroma.Error:               "underline decoration-wavy decoration-accent-deep",
+}
+
+// CodeBlockRenderer highlights fenced code blocks on the server. The info string
+// takes a language and an optional brace block, e.g. "go {3-5,8 linenos}", where
+// numbers and ranges mark highlighted lines and "linenos" enables line numbers.
+type CodeBlockRenderer struct {
+	html.Config
+	infoRe *regexp.Regexp
+}
+
+func NewCodeBlockRenderer(opts ...html.Option) renderer.NodeRenderer {
+	r := &CodeBlockRenderer{
+		Config: html.NewConfig(),
+		infoRe: regexp.MustCompile(`^\s*([^\s{]*)\s*(?:\{([^}]*)\})?`),
+	}
+	for _, opt := range opts {
+		opt.SetHTMLOption(&r.Config)
+	}
+	return r
+}
+
+func (r *CodeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+}
+
+func (r *CodeBlockRenderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+
+	var info []byte
+	if n.Info != nil {
+		info = n.Info.Segment.Value(source)
+	}
+	lang, highlighted, lineNumbers := r.parseInfo(info)
+
+	var code bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		code.Write(line.Value(source))
+	}
+
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, code.String())
+	if err != nil {
+		slog.Warn("failed to tokenise code block, rendering it as plain text", slog.String("lang", lang), slog.String("error", err.Error()))
+		iterator = chroma.Literator(chroma.Token{Type: chroma.Text, Value: code.String()})
+	}
+
+	_, _ = w.WriteString("<pre")
+	if n.Attributes() != nil {
+		html.RenderAttributes(w, n, html.GlobalAttributeFilter)
+	}
+	if lang != "" {
+		_, _ = w.WriteString(` data-lang="`)
+		_, _ = w.Write(util.EscapeHTML([]byte(lang)))
+		_, _ = w.WriteString(`"`)
+	}
+	_, _ = w.WriteString("><code class=\"grid font-mono text-sm/[1.5] w-fit min-w-full\">")
+
+	for i, tokens := range chroma.SplitTokensIntoLines(iterator.Tokens()) {
+		lineNumber := i + 1
+		if _, ok := highlighted[lineNumber]; ok {
+			_, _ = w.WriteString(`<span class="flex bg-accent-light/50 border-l-[0.25rem] border-accent-deep -ml-1 pl-0.5">`)
+		} else {
+			_, _ = w.WriteString(`<span class="flex">`)
+		}
+		if lineNumbers {
+			_, _ = w.WriteString(`<span class="select-none shrink-0 w-8 pr-2 mr-2 text-right text-main-soft border-r border-dotted border-main-soft">`)
+			_, _ = w.WriteString(strconv.Itoa(lineNumber))
+			_, _ = w.WriteString(`</span>`)
+		}
+		_, _ = w.WriteString(`<span class="whitespace-pre min-h-[1.5em]">`)
+		for _, token := range tokens {
+			value := strings.TrimRight(token.Value, "\n")
+			if value == "" {
+				continue
+			}
+			class := tokenClass(token.Type)
+			if class == "" {
+				_, _ = w.Write(util.EscapeHTML([]byte(value)))
+				continue
+			}
+			_, _ = w.WriteString(`<span class="` + class + `">`)
+			_, _ = w.Write(util.EscapeHTML([]byte(value)))
+			_, _ = w.WriteString(`</span>`)
+		}
+		_, _ = w.WriteString("</span></span>\n")
+	}
+
+	_, _ = w.WriteString("</code></pre>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *CodeBlockRenderer) parseInfo(info []byte) (lang string, highlighted map[int]struct{}, lineNumbers bool) {
+	highlighted = make(map[int]struct{})
+
+	parts := r.infoRe.FindSubmatch(info)
+	if parts == nil {
+		return "", highlighted, false
+	}
+	lang = strings.ToLower(string(parts[1]))
+
+	for _, option := range strings.FieldsFunc(string(parts[2]), func(r rune) bool { return r == ',' || r == ' ' }) {
+		if option == "linenos" {
+			lineNumbers = true
+			continue
+		}
+
+		from, to, isRange := strings.Cut(option, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			slog.Warn("invalid option in code block info string", slog.String("option", option), slog.String("info", string(info)))
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil || end < start {
+				slog.Warn("invalid line range in code block info string", slog.String("option", option), slog.String("info", string(info)))
+				continue
+			}
+		}
+		for line := start; line <= end; line++ {
+			highlighted[line] = struct{}{}
+		}
+	}
+
+	return lang, highlighted, lineNumbers
+}
+
+func tokenClass(tokenType chroma.TokenType) string {
+	for _, t := range []chroma.TokenType{tokenType, tokenType.SubCategory(), tokenType.Category()} {
+		if class, ok := tokenClasses[t]; ok {
+			return class
+		}
+	}
+	return ""
+}
@@ -15,7 +15,7 @@ func NewTailwindExtension() goldmark.Extender {
 func (e *TailwindExtension) Extend(m goldmark.Markdown) {
 	m.Parser().AddOptions(
 		parser.WithASTTransformers(
-			util.Prioritized(&TailwindTransformer{}, 500),
+			util.Prioritized(&TailwindTransformer{}, 1000),
 		),
 	)
 }
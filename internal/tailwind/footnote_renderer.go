@@ -0,0 +1,80 @@
+package tailwind
+
+import (
+	"strconv"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// CustomFootnoteRenderer renders footnote references, backlinks and the footnote
+// list with the attributes set by TailwindTransformer (the stock renderer takes
+// classes from its config only).
+type CustomFootnoteRenderer struct {
+	html.Config
+}
+
+func NewCustomFootnoteRenderer(opts ...html.Option) renderer.NodeRenderer {
+	r := &CustomFootnoteRenderer{
+		Config: html.NewConfig(),
+	}
+	for _, opt := range opts {
+		opt.SetHTMLOption(&r.Config)
+	}
+	return r
+}
+
+func (r *CustomFootnoteRenderer) renderFootnoteLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		n := node.(*east.FootnoteLink)
+		is := strconv.Itoa(n.Index)
+		_, _ = w.WriteString(`<sup id="fnref`)
+		if n.RefIndex > 0 {
+			_, _ = w.WriteString(strconv.Itoa(n.RefIndex))
+		}
+		_, _ = w.WriteString(":" + is + `"><a href="#fn:` + is + `"`)
+		if n.Attributes() != nil {
+			html.RenderAttributes(w, n, html.LinkAttributeFilter)
+		}
+		_, _ = w.WriteString(` role="doc-noteref">` + is + `</a></sup>`)
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *CustomFootnoteRenderer) renderFootnoteBacklink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		n := node.(*east.FootnoteBacklink)
+		_, _ = w.WriteString(`&#160;<a href="#fnref`)
+		if n.RefIndex > 0 {
+			_, _ = w.WriteString(strconv.Itoa(n.RefIndex))
+		}
+		_, _ = w.WriteString(":" + strconv.Itoa(n.Index) + `"`)
+		if n.Attributes() != nil {
+			html.RenderAttributes(w, n, html.LinkAttributeFilter)
+		}
+		_, _ = w.WriteString(` role="doc-backlink">&#x21a9;&#xfe0e;</a>`)
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *CustomFootnoteRenderer) renderFootnoteList(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString("<div class=\"footnotes\" role=\"doc-endnotes\">\n<ol")
+		if node.Attributes() != nil {
+			html.RenderAttributes(w, node, html.ListAttributeFilter)
+		}
+		_, _ = w.WriteString(">\n")
+	} else {
+		_, _ = w.WriteString("</ol>\n</div>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *CustomFootnoteRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(east.KindFootnoteLink, r.renderFootnoteLink)
+	reg.Register(east.KindFootnoteBacklink, r.renderFootnoteBacklink)
+	reg.Register(east.KindFootnoteList, r.renderFootnoteList)
+}
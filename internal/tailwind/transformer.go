@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/SayaAndy/saya-today-web/internal/admonition"
+	"github.com/SayaAndy/saya-today-web/internal/wikilink"
 	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
 )
@@ -32,7 +35,14 @@ func (t *TailwindTransformer) Transform(node *ast.Document, reader text.Reader,
 			}
 
 		case *ast.Paragraph:
-			node.SetAttribute([]byte("class"), []byte("text-base/[2] font-gentium tracking-[.0125rem] -indent-8 ml-4 mb-8"))
+			switch node.Parent().(type) {
+			case *east.Footnote:
+				node.SetAttribute([]byte("class"), []byte("inline"))
+			case *admonition.AdmonitionBlock:
+				node.SetAttribute([]byte("class"), []byte("text-base/[2] font-gentium tracking-[.0125rem] mb-2 last:mb-0"))
+			default:
+				node.SetAttribute([]byte("class"), []byte("text-base/[2] font-gentium tracking-[.0125rem] -indent-8 ml-4 mb-8"))
+			}
 
 		case *ast.List:
 			if node.IsOrdered() {
@@ -42,7 +52,11 @@ func (t *TailwindTransformer) Transform(node *ast.Document, reader text.Reader,
 			}
 
 		case *ast.ListItem:
-			node.SetAttribute([]byte("class"), []byte("text-base/[2] font-gentium tracking-[.0125rem]"))
+			if isTaskListItem(node) {
+				node.SetAttribute([]byte("class"), []byte("text-base/[2] font-gentium tracking-[.0125rem] list-none -ml-4"))
+			} else {
+				node.SetAttribute([]byte("class"), []byte("text-base/[2] font-gentium tracking-[.0125rem]"))
+			}
 
 		case *ast.Blockquote:
 			node.SetAttribute([]byte("class"), []byte("border-l-[0.125rem] border-main-medium bg-paper bg-background-dark p-1 mb-2 italic font-thin"))
@@ -60,9 +74,51 @@ func (t *TailwindTransformer) Transform(node *ast.Document, reader text.Reader,
 			}
 			node.SetAttribute([]byte("class"), []byte("text-secondary hover:text-main-hard underline"))
 
+		case *wikilink.BrokenLink:
+			node.SetAttribute([]byte("class"), []byte("text-main-soft underline decoration-wavy decoration-accent-deep cursor-help"))
+
 		case *ast.Image:
 			node.SetAttribute([]byte("class"), []byte("max-w-full h-auto rounded-lg shadow-lg mb-4"))
 
+		case *admonition.AdmonitionBlock:
+			classes := map[string]string{
+				admonition.Note:      "border-l-[0.375rem] border-main-medium bg-paper bg-background-dark rounded-lg p-2 mb-8",
+				admonition.Tip:       "border-l-[0.375rem] border-accent-light bg-paper bg-background-dark rounded-lg p-2 mb-8",
+				admonition.Warning:   "border-l-[0.375rem] border-accent-deep bg-paper bg-accent-light/40 rounded-lg p-2 mb-8",
+				admonition.Spoiler:   "border-[0.125rem] border-dotted border-main-medium bg-paper bg-background-dark rounded-lg p-2 mb-8 open:pb-2",
+				admonition.TravelTip: "border-l-[0.375rem] border-double border-secondary bg-paper bg-background-medium rounded-lg p-2 mb-8",
+			}
+			if class, ok := classes[node.AdmonitionKind]; ok {
+				node.SetAttribute([]byte("class"), []byte(class))
+			}
+
+		case *east.Table:
+			node.SetAttribute([]byte("class"), []byte("block w-fit max-w-full overflow-x-auto border-collapse font-gentium tracking-[.0125rem] mx-auto mb-8"))
+
+		case *east.TableHeader:
+			node.SetAttribute([]byte("class"), []byte("bg-paper bg-background-dark text-main-hard font-bold"))
+
+		case *east.TableRow:
+			node.SetAttribute([]byte("class"), []byte("even:bg-paper even:bg-background-dark"))
+
+		case *east.TableCell:
+			node.SetAttribute([]byte("class"), []byte("border-[0.125rem] border-dotted border-main-medium px-2 py-1 text-base/[1.5]"))
+
+		case *east.Strikethrough:
+			node.SetAttribute([]byte("class"), []byte("line-through decoration-main-medium"))
+
+		case *east.FootnoteLink:
+			node.SetAttribute([]byte("class"), []byte("text-secondary hover:text-main-hard font-m-plus px-0.5"))
+
+		case *east.FootnoteBacklink:
+			node.SetAttribute([]byte("class"), []byte("text-secondary hover:text-main-hard no-underline"))
+
+		case *east.FootnoteList:
+			node.SetAttribute([]byte("class"), []byte("list-decimal list-inside space-y-1 text-sm font-gentium tracking-[.0125rem] border-t-[0.375rem] border-dotted border-main-hard pt-2 mt-4 mb-4 pl-4"))
+
+		case *east.Footnote:
+			node.SetAttribute([]byte("class"), []byte("text-sm/[1.75] font-gentium tracking-[.0125rem] target:bg-accent-light target:bg-paper"))
+
 		case *ast.Emphasis:
 			switch node.Level {
 			case 1:
@@ -77,3 +133,12 @@ func (t *TailwindTransformer) Transform(node *ast.Document, reader text.Reader,
 		return ast.WalkContinue, nil
 	})
 }
+
+func isTaskListItem(node *ast.ListItem) bool {
+	block := node.FirstChild()
+	if block == nil {
+		return false
+	}
+	_, ok := block.FirstChild().(*east.TaskCheckBox)
+	return ok
+}
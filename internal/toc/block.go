@@ -0,0 +1,31 @@
+package toc
+
+import (
+	"github.com/yuin/goldmark/ast"
+)
+
+// TOCBlock represents a [[toc]] placeholder in the AST
+type TOCBlock struct {
+	ast.BaseBlock
+	Items []*Item
+}
+
+// Item is a single heading in the table of contents
+type Item struct {
+	ID       string
+	Title    string
+	Level    int
+	Children []*Item
+}
+
+var KindTOCBlock = ast.NewNodeKind("TOCBlock")
+
+// Dump implements ast.Node.Dump
+func (n *TOCBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// Kind implements ast.Node.Kind
+func (n *TOCBlock) Kind() ast.NodeKind {
+	return KindTOCBlock
+}
@@ -0,0 +1,58 @@
+package toc
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// ContentSettingsKey is the frontmatter contentSettings key that turns the table of contents off
+const ContentSettingsKey = "toc"
+
+var (
+	itemsContextKey    = parser.NewContextKey()
+	disabledContextKey = parser.NewContextKey()
+)
+
+// Extension that combines transformer and renderer
+type TOCExtension struct{}
+
+func NewTOCExtension() goldmark.Extender {
+	return &TOCExtension{}
+}
+
+func (e *TOCExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithASTTransformers(
+			util.Prioritized(&TOCTransformer{}, 100),
+		),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(
+			util.Prioritized(NewTOCHTMLRenderer(), 500),
+		),
+	)
+}
+
+// Enabled tells whether the table of contents is allowed by the post's contentSettings
+func Enabled(contentSettings map[string]string) bool {
+	switch strings.ToLower(strings.TrimSpace(contentSettings[ContentSettingsKey])) {
+	case "false", "off", "no", "disabled":
+		return false
+	}
+	return true
+}
+
+// Disable makes the transformer skip collecting headings and drop [[toc]] placeholders
+func Disable(pc parser.Context) {
+	pc.Set(disabledContextKey, true)
+}
+
+// Get returns the table of contents collected while parsing a document with the given context
+func Get(pc parser.Context) []*Item {
+	items, _ := pc.Get(itemsContextKey).([]*Item)
+	return items
+}
@@ -0,0 +1,62 @@
+package toc
+
+import (
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+type TOCHTMLRenderer struct {
+	html.Config
+}
+
+func NewTOCHTMLRenderer(opts ...html.Option) renderer.NodeRenderer {
+	r := &TOCHTMLRenderer{
+		Config: html.NewConfig(),
+	}
+	for _, opt := range opts {
+		opt.SetHTMLOption(&r.Config)
+	}
+	return r
+}
+
+func (r *TOCHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindTOCBlock, r.renderTOC)
+}
+
+func (r *TOCHTMLRenderer) renderTOC(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		block := n.(*TOCBlock)
+
+		w.WriteString(`
+<nav class="toc bg-paper bg-background-dark border-l-[0.125rem] border-main-medium rounded-lg p-2 mb-8 w-fit">`)
+		writeItems(w, block.Items)
+		w.WriteString(`
+</nav>
+`)
+	}
+
+	return ast.WalkSkipChildren, nil
+}
+
+func writeItems(w util.BufWriter, items []*Item) {
+	if len(items) == 0 {
+		return
+	}
+
+	w.WriteString(`
+<ol class="list-decimal list-inside space-y-1 font-gentium tracking-[.0125rem] pl-4">`)
+	for _, item := range items {
+		w.WriteString(`
+	<li class="text-base/[1.75]"><a href="#`)
+		w.Write(util.EscapeHTML([]byte(item.ID)))
+		w.WriteString(`" class="text-secondary hover:text-main-hard underline">`)
+		w.Write(util.EscapeHTML([]byte(item.Title)))
+		w.WriteString(`</a>`)
+		writeItems(w, item.Children)
+		w.WriteString(`</li>`)
+	}
+	w.WriteString(`
+</ol>`)
+}
@@ -0,0 +1,94 @@
+package toc
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+type TOCTransformer struct{}
+
+func (t *TOCTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	disabled, _ := pc.Get(disabledContextKey).(bool)
+
+	items := make([]*Item, 0)
+	placeholders := make([]ast.Node, 0)
+
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.Paragraph:
+			if node.Lines().Len() == 1 && bytes.Equal(bytes.TrimSpace(node.Lines().Value(source)), []byte("[[toc]]")) {
+				placeholders = append(placeholders, node)
+			}
+			return ast.WalkSkipChildren, nil
+
+		case *ast.Heading:
+			if disabled || node.Level < 2 || node.Level > 3 {
+				return ast.WalkSkipChildren, nil
+			}
+			id, ok := node.AttributeString("id")
+			if !ok {
+				return ast.WalkSkipChildren, nil
+			}
+			idBytes, _ := id.([]byte)
+
+			item := &Item{ID: string(idBytes), Title: plainText(node, source), Level: node.Level}
+			if node.Level == 3 && len(items) > 0 && items[len(items)-1].Level == 2 {
+				parent := items[len(items)-1]
+				parent.Children = append(parent.Children, item)
+			} else {
+				items = append(items, item)
+			}
+			return ast.WalkSkipChildren, nil
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	for _, placeholder := range placeholders {
+		parent := placeholder.Parent()
+		if disabled || len(items) == 0 {
+			parent.RemoveChild(parent, placeholder)
+			continue
+		}
+		parent.ReplaceChild(parent, placeholder, &TOCBlock{Items: items})
+	}
+
+	if !disabled {
+		pc.Set(itemsContextKey, items)
+	}
+}
+
+func plainText(node ast.Node, source []byte) string {
+	var buf bytes.Buffer
+	ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			buf.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(n.Value)
+		case *ast.CodeSpan:
+			for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+				if t, ok := c.(*ast.Text); ok {
+					buf.Write(t.Segment.Value(source))
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return buf.String()
+}
@@ -0,0 +1,57 @@
+package webmention
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+const (
+	// maxBodySize bounds how much of a fetched page is read
+	maxBodySize  = 1 << 20
+	maxRedirects = 5
+	userAgent    = "saya-today-web webmention"
+)
+
+var ErrPrivateAddress = errors.New("address is not public")
+
+// NewClient returns the HTTP client webmentions are fetched and sent with.
+// Unless allowPrivateNetworks is set, it refuses to connect to loopback,
+// private and link-local addresses, as the URLs come from strangers
+func NewClient(allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivateNetworks {
+		dialer.Control = func(network string, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("fail to parse dialed address '%s': %w", address, err)
+			}
+			addr := addrPort.Addr().Unmap()
+			if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+				return fmt.Errorf("refuse to dial '%s': %w", address, ErrPrivateAddress)
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: 15 * time.Second,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
+			MaxIdleConns:          16,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package webmention
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	tagRegexp       = regexp.MustCompile(`(?is)<(a|link)\b([^>]*)>`)
+	attributeRegexp = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	titleRegexp     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	commentRegexp   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	linkHeaderPart  = regexp.MustCompile(`<([^>]*)>((?:\s*;\s*[^;,]+)*)`)
+	relParamRegexp  = regexp.MustCompile(`(?i)rel\s*=\s*(?:"([^"]*)"|([^\s;,]+))`)
+)
+
+type tag struct {
+	name       string
+	attributes map[string]string
+}
+
+// scanTags returns the <a> and <link> tags of the page in document order. It
+// is not a full HTML parser, but it is enough to find links in real pages
+func scanTags(body []byte) []tag {
+	body = commentRegexp.ReplaceAll(body, nil)
+
+	tags := make([]tag, 0)
+	for _, match := range tagRegexp.FindAllSubmatch(body, -1) {
+		attributes := make(map[string]string)
+		for _, attribute := range attributeRegexp.FindAllSubmatch(match[2], -1) {
+			name := strings.ToLower(string(attribute[1]))
+			if _, ok := attributes[name]; ok {
+				continue
+			}
+			value := attribute[2]
+			if value == nil {
+				value = attribute[3]
+			}
+			if value == nil {
+				value = attribute[4]
+			}
+			attributes[name] = html.UnescapeString(string(value))
+		}
+		tags = append(tags, tag{strings.ToLower(string(match[1])), attributes})
+	}
+	return tags
+}
+
+func hasRel(rel string, value string) bool {
+	return slices.ContainsFunc(strings.Fields(rel), func(r string) bool {
+		return strings.EqualFold(r, value)
+	})
+}
+
+// endpointFromHeaders returns the first webmention endpoint advertised in the
+// Link headers
+func endpointFromHeaders(linkHeaders []string) (endpoint string, ok bool) {
+	for _, header := range linkHeaders {
+		for _, part := range linkHeaderPart.FindAllStringSubmatch(header, -1) {
+			for _, rel := range relParamRegexp.FindAllStringSubmatch(part[2], -1) {
+				if hasRel(rel[1]+rel[2], "webmention") {
+					return part[1], true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// endpointFromBody returns the first webmention endpoint advertised by a
+// <link> or <a> tag of the page
+func endpointFromBody(body []byte) (endpoint string, ok bool) {
+	for _, t := range scanTags(body) {
+		href, hasHref := t.attributes["href"]
+		if hasHref && hasRel(t.attributes["rel"], "webmention") {
+			return href, true
+		}
+	}
+	return "", false
+}
+
+// linksTo reports whether the page at base contains a link to target
+func linksTo(body []byte, base *url.URL, target string) bool {
+	for _, t := range scanTags(body) {
+		href, ok := t.attributes["href"]
+		if !ok {
+			continue
+		}
+		resolved, err := base.Parse(strings.TrimSpace(href))
+		if err != nil {
+			continue
+		}
+		if sameURL(resolved.String(), target) {
+			return true
+		}
+	}
+	return false
+}
+
+func pageTitle(body []byte) string {
+	match := titleRegexp.FindSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	title := strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+	if len([]rune(title)) > 256 {
+		title = string([]rune(title)[:256])
+	}
+	return title
+}
+
+// sameURL compares two URLs ignoring the fragment and a trailing slash
+func sameURL(a string, b string) bool {
+	normalize := func(raw string) string {
+		raw, _, _ = strings.Cut(raw, "#")
+		return strings.TrimSuffix(raw, "/")
+	}
+	return normalize(a) == normalize(b)
+}
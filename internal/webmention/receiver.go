@@ -0,0 +1,154 @@
+package webmention
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const queueSize = 256
+
+var ErrQueueFull = errors.New("webmention queue is full")
+
+type Mention struct {
+	Source     string
+	Title      string
+	VerifiedAt time.Time
+}
+
+type request struct {
+	source string
+	target string
+	page   string
+	lang   string
+}
+
+// Receiver stores webmentions of blog pages. A mention is verified in the
+// background by fetching its source, and is dropped once the source stops
+// linking to the page
+type Receiver struct {
+	db     *sql.DB
+	client *http.Client
+	queue  chan request
+	wg     sync.WaitGroup
+}
+
+func NewReceiver(db *sql.DB, client *http.Client) *Receiver {
+	r := &Receiver{
+		db:     db,
+		client: client,
+		queue:  make(chan request, queueSize),
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for req := range r.queue {
+			if err := r.verify(req); err != nil {
+				slog.Warn("failed to verify webmention",
+					slog.String("source", req.source),
+					slog.String("target", req.target),
+					slog.String("error", err.Error()))
+			}
+		}
+	}()
+	return r
+}
+
+// Enqueue schedules verification of a mention of the page by source
+func (r *Receiver) Enqueue(source string, target string, page string, lang string) error {
+	select {
+	case r.queue <- request{source, target, page, lang}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Mentions returns the verified mentions of the page, newest first
+func (r *Receiver) Mentions(page string, lang string) ([]*Mention, error) {
+	rows, err := r.db.Query(`SELECT source, title, verified_at FROM webmention_table
+  WHERE page_ref=? AND lang=? ORDER BY verified_at DESC;`, page, lang)
+	if err != nil {
+		return nil, fmt.Errorf("fail to query webmentions: %w", err)
+	}
+	defer rows.Close()
+
+	mentions := make([]*Mention, 0)
+	for rows.Next() {
+		var mention Mention
+		var verifiedAt int64
+		if err := rows.Scan(&mention.Source, &mention.Title, &verifiedAt); err != nil {
+			return nil, fmt.Errorf("fail scanning webmentions: %w", err)
+		}
+		mention.VerifiedAt = time.Unix(verifiedAt, 0)
+		mentions = append(mentions, &mention)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fail reading webmentions: %w", err)
+	}
+	return mentions, nil
+}
+
+// Close waits for the queued mentions to be verified
+func (r *Receiver) Close() {
+	close(r.queue)
+	r.wg.Wait()
+}
+
+func (r *Receiver) verify(req request) error {
+	httpRequest, err := http.NewRequest("GET", req.source, nil)
+	if err != nil {
+		return fmt.Errorf("fail to build request to source: %w", err)
+	}
+	httpRequest.Header.Set("User-Agent", userAgent)
+	httpRequest.Header.Set("Accept", "text/html, */*;q=0.5")
+
+	response, err := r.client.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("fail to fetch source: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusGone {
+		return r.delete(req)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("source responded with status %d", response.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxBodySize))
+	if err != nil {
+		return fmt.Errorf("fail to read source: %w", err)
+	}
+
+	base, err := url.Parse(req.source)
+	if err != nil {
+		return fmt.Errorf("fail to parse source: %w", err)
+	}
+	if !linksTo(body, base, req.target) {
+		slog.Info("webmention source does not link to the target", slog.String("source", req.source), slog.String("target", req.target))
+		return r.delete(req)
+	}
+
+	if _, err = r.db.Exec(`INSERT INTO webmention_table(source, page_ref, lang, title, verified_at) VALUES(?, ?, ?, ?, ?)
+  ON CONFLICT(source, page_ref, lang) DO UPDATE SET title=excluded.title, verified_at=excluded.verified_at;`,
+		req.source, req.page, req.lang, pageTitle(body), time.Now().Unix()); err != nil {
+		return fmt.Errorf("fail to save webmention: %w", err)
+	}
+	slog.Info("webmention verified", slog.String("source", req.source), slog.String("target", req.target))
+	return nil
+}
+
+func (r *Receiver) delete(req request) error {
+	if _, err := r.db.Exec(`DELETE FROM webmention_table WHERE source=? AND page_ref=? AND lang=?;`, req.source, req.page, req.lang); err != nil {
+		return fmt.Errorf("fail to delete webmention: %w", err)
+	}
+	return nil
+}
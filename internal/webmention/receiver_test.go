@@ -0,0 +1,121 @@
+package webmention
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const testTarget = "https://saya.uz/en/blog/first-post"
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+t.TempDir()+"/webmention.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migration, err := os.ReadFile("../../migrations/8_create_webmentions_table.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec(string(migration)); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// receive queues a mention of the test target by source and waits until it is
+// verified
+func receive(t *testing.T, db *sql.DB, source string) []*Mention {
+	t.Helper()
+	receiver := NewReceiver(db, NewClient(true))
+	if err := receiver.Enqueue(source, testTarget, "first-post", "en"); err != nil {
+		t.Fatal(err)
+	}
+	receiver.Close()
+
+	mentions, err := receiver.Mentions("first-post", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mentions
+}
+
+func TestReceiverVerifiesAndDeletes(t *testing.T) {
+	// 0 links to the target, 1 no longer does, 2 is gone
+	var state atomic.Int32
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch state.Load() {
+		case 0:
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><head><title>A reply</title></head><body><a href="%s">nice post</a></body></html>`, testTarget)
+		case 1:
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><title>A reply</title></head><body>no links here</body></html>`)
+		default:
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer source.Close()
+	db := newTestDB(t)
+
+	mentions := receive(t, db, source.URL)
+	if len(mentions) != 1 || mentions[0].Source != source.URL || mentions[0].Title != "A reply" {
+		t.Fatalf("expected a verified mention titled 'A reply', got %+v", mentions)
+	}
+
+	state.Store(1)
+	if mentions = receive(t, db, source.URL); len(mentions) != 0 {
+		t.Fatalf("expected the mention to be deleted once the link is gone, got %d", len(mentions))
+	}
+
+	state.Store(0)
+	receive(t, db, source.URL)
+	state.Store(2)
+	if mentions = receive(t, db, source.URL); len(mentions) != 0 {
+		t.Fatalf("expected the mention to be deleted once the source is gone, got %d", len(mentions))
+	}
+}
+
+func TestReceiverKeepsMentionOnFailedFetch(t *testing.T) {
+	var failing atomic.Bool
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<a href="%s">link</a>`, testTarget)
+	}))
+	defer source.Close()
+	db := newTestDB(t)
+
+	receive(t, db, source.URL)
+	failing.Store(true)
+	if mentions := receive(t, db, source.URL); len(mentions) != 1 {
+		t.Fatalf("expected the mention to survive a failing source, got %d", len(mentions))
+	}
+}
+
+func TestReceiverRefusesPrivateSources(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<a href="%s">link</a>`, testTarget)
+	}))
+	defer source.Close()
+	db := newTestDB(t)
+
+	receiver := NewReceiver(db, NewClient(false))
+	if err := receiver.verify(request{source.URL, testTarget, "first-post", "en"}); !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("expected fetching a loopback source to fail with ErrPrivateAddress, got %v", err)
+	}
+	receiver.Close()
+}
@@ -0,0 +1,157 @@
+package webmention
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/SayaAndy/saya-today-web/internal/mdcontext"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Sender notifies other sites that a blog page links to them
+type Sender struct {
+	client *http.Client
+}
+
+func NewSender(client *http.Client) *Sender {
+	return &Sender{client: client}
+}
+
+// Send sends a webmention from source to every target advertising an
+// endpoint, targets without one are skipped
+func (s *Sender) Send(source string, targets []string) (sent int, err error) {
+	errs := make([]error, 0)
+	for _, target := range targets {
+		endpoint, err := s.discover(target)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fail to discover webmention endpoint of '%s': %w", target, err))
+			continue
+		}
+		if endpoint == "" {
+			slog.Debug("target has no webmention endpoint", slog.String("target", target))
+			continue
+		}
+
+		if err = s.post(endpoint, source, target); err != nil {
+			errs = append(errs, fmt.Errorf("fail to send webmention to '%s': %w", target, err))
+			continue
+		}
+		slog.Info("webmention sent", slog.String("source", source), slog.String("target", target), slog.String("endpoint", endpoint))
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// discover returns the resolved webmention endpoint of the target or an empty
+// string if it does not advertise one
+func (s *Sender) discover(target string) (string, error) {
+	request, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set("Accept", "text/html, */*;q=0.5")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return "", fmt.Errorf("target responded with status %d", response.StatusCode)
+	}
+
+	endpoint, ok := endpointFromHeaders(response.Header.Values("Link"))
+	if !ok && strings.Contains(response.Header.Get("Content-Type"), "html") {
+		body, err := io.ReadAll(io.LimitReader(response.Body, maxBodySize))
+		if err != nil {
+			return "", err
+		}
+		endpoint, ok = endpointFromBody(body)
+	}
+	if !ok {
+		return "", nil
+	}
+
+	resolved, err := response.Request.URL.Parse(strings.TrimSpace(endpoint))
+	if err != nil {
+		return "", fmt.Errorf("fail to resolve endpoint '%s': %w", endpoint, err)
+	}
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return "", fmt.Errorf("endpoint '%s' is not http(s)", resolved)
+	}
+	return resolved.String(), nil
+}
+
+func (s *Sender) post(endpoint string, source string, target string) error {
+	request, err := http.NewRequest("POST", endpoint, strings.NewReader(url.Values{
+		"source": {source},
+		"target": {target},
+	}.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, maxBodySize))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("endpoint responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// ExternalLinks returns the absolute http(s) links of the post that lead to
+// other hosts than ownHost
+func ExternalLinks(md goldmark.Markdown, markdown []byte, lang string, codename string, ownHost string) []string {
+	pc := parser.NewContext()
+	mdcontext.SetLang(pc, lang)
+	mdcontext.SetCodename(pc, codename)
+	document := md.Parser().Parse(text.NewReader(markdown), parser.WithContext(pc))
+
+	links := make([]string, 0)
+	ast.Walk(document, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		var destination string
+		switch node := n.(type) {
+		case *ast.Link:
+			destination = string(node.Destination)
+		case *ast.AutoLink:
+			if node.AutoLinkType != ast.AutoLinkURL {
+				return ast.WalkContinue, nil
+			}
+			destination = string(node.URL(markdown))
+		default:
+			return ast.WalkContinue, nil
+		}
+
+		link, err := url.Parse(destination)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" || strings.EqualFold(link.Host, ownHost) {
+			return ast.WalkContinue, nil
+		}
+		if !slices.Contains(links, link.String()) {
+			links = append(links, link.String())
+		}
+		return ast.WalkContinue, nil
+	})
+	return links
+}
@@ -0,0 +1,123 @@
+package webmention
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+type receivedMention struct {
+	endpoint string
+	source   string
+	target   string
+}
+
+// endpoints records the webmentions posted to any of its paths
+type endpoints struct {
+	mu       sync.Mutex
+	received []receivedMention
+}
+
+func (e *endpoints) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	e.mu.Lock()
+	e.received = append(e.received, receivedMention{r.URL.Path, r.FormValue("source"), r.FormValue("target")})
+	e.mu.Unlock()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func TestSenderDiscoversAndPosts(t *testing.T) {
+	const source = "https://saya.uz/en/blog/first-post"
+	receiver := &endpoints{}
+	endpointServer := httptest.NewServer(receiver)
+	defer endpointServer.Close()
+
+	targets := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/by-header":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/header>; rel="webmention"`, endpointServer.URL))
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html></html>`)
+		case "/by-html":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><head><link rel="webmention" href="%s/html"></head></html>`, endpointServer.URL)
+		case "/relative":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><link rel="webmention" href="/endpoint"></head></html>`)
+		case "/endpoint":
+			receiver.ServeHTTP(w, r)
+		case "/none":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><body>no endpoint</body></html>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer targets.Close()
+
+	sender := NewSender(NewClient(true))
+	sent, err := sender.Send(source, []string{
+		targets.URL + "/by-header",
+		targets.URL + "/by-html",
+		targets.URL + "/relative",
+		targets.URL + "/none",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 3 {
+		t.Fatalf("expected 3 webmentions sent, got %d", sent)
+	}
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	for _, expected := range []receivedMention{
+		{"/header", source, targets.URL + "/by-header"},
+		{"/html", source, targets.URL + "/by-html"},
+		{"/endpoint", source, targets.URL + "/relative"},
+	} {
+		if !slices.Contains(receiver.received, expected) {
+			t.Errorf("expected %+v among received webmentions %+v", expected, receiver.received)
+		}
+	}
+}
+
+func TestSenderReportsFailedTargets(t *testing.T) {
+	targets := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rejecting":
+			w.Header().Set("Link", `</endpoint>; rel="webmention"`)
+		case "/endpoint":
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer targets.Close()
+
+	sent, err := NewSender(NewClient(true)).Send("https://saya.uz/en/blog/first-post", []string{
+		targets.URL + "/rejecting",
+		targets.URL + "/missing",
+	})
+	if sent != 0 || err == nil {
+		t.Fatalf("expected no webmentions sent and an error, got %d and %v", sent, err)
+	}
+}
+
+func TestSenderRefusesPrivateTargets(t *testing.T) {
+	targets := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `</endpoint>; rel="webmention"`)
+	}))
+	defer targets.Close()
+
+	if _, err := NewSender(NewClient(false)).discover(targets.URL); !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("expected discovering a loopback target to fail with ErrPrivateAddress, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package wikilink
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SayaAndy/saya-today-web/internal/blog"
+)
+
+// Catalog resolves post codenames into titles, keeping the scanned list of
+// posts per language for the given ttl
+type Catalog struct {
+	blogClient blog.Client
+	ttl        time.Duration
+	mu         sync.Mutex
+	langs      map[string]*catalogEntry
+}
+
+type catalogEntry struct {
+	scannedAt time.Time
+	titles    map[string]string
+}
+
+func NewCatalog(blogClient blog.Client, ttl time.Duration) *Catalog {
+	return &Catalog{
+		blogClient: blogClient,
+		ttl:        ttl,
+		langs:      make(map[string]*catalogEntry),
+	}
+}
+
+// Resolve returns the title of the post with the codename in the given language.
+// If the catalog cannot be scanned, the link is not reported as broken.
+func (c *Catalog) Resolve(lang string, codename string) (title string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.langs[lang]
+	if !found || time.Since(entry.scannedAt) > c.ttl {
+		pages, err := c.blogClient.Scan(lang + "/")
+		if err != nil {
+			slog.Warn("failed to scan blog pages for resolving wiki links", slog.String("lang", lang), slog.String("error", err.Error()))
+			if !found {
+				return codename, true
+			}
+		} else {
+			entry = &catalogEntry{scannedAt: time.Now(), titles: make(map[string]string, len(pages))}
+			for _, page := range pages {
+				title := page.FileName
+				if page.Metadata != nil && page.Metadata.Title != "" {
+					title = page.Metadata.Title
+				}
+				entry.titles[page.FileName] = title
+			}
+			c.langs[lang] = entry
+		}
+	}
+
+	title, ok = entry.titles[strings.TrimSuffix(codename, ".md")]
+	return title, ok
+}
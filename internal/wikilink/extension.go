@@ -0,0 +1,31 @@
+package wikilink
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// Extension that combines parser and renderer
+type WikiLinkExtension struct {
+	resolver Resolver
+}
+
+func NewWikiLinkExtension(resolver Resolver) goldmark.Extender {
+	return &WikiLinkExtension{resolver}
+}
+
+func (e *WikiLinkExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(
+			// has to run before the standard link parser (200), which also triggers on '['
+			util.Prioritized(NewWikiLinkParser(e.resolver), 199),
+		),
+	)
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(
+			util.Prioritized(NewWikiLinkHTMLRenderer(), 500),
+		),
+	)
+}
@@ -0,0 +1,52 @@
+package wikilink
+
+import (
+	"github.com/SayaAndy/saya-today-web/l10n"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+type WikiLinkHTMLRenderer struct {
+	html.Config
+}
+
+func NewWikiLinkHTMLRenderer(opts ...html.Option) renderer.NodeRenderer {
+	r := &WikiLinkHTMLRenderer{
+		Config: html.NewConfig(),
+	}
+	for _, opt := range opts {
+		opt.SetHTMLOption(&r.Config)
+	}
+	return r
+}
+
+func (r *WikiLinkHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindBrokenLink, r.renderBrokenLink)
+}
+
+func (r *WikiLinkHTMLRenderer) renderBrokenLink(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</span>")
+		return ast.WalkContinue, nil
+	}
+	link := n.(*BrokenLink)
+
+	lang := link.Lang
+	if lang == "" {
+		lang = "en"
+	}
+	hint, _ := l10n.T.GetPath(lang, "WikiLink", "Broken").(string)
+
+	_, _ = w.WriteString(`<span data-broken-link="`)
+	_, _ = w.Write(util.EscapeHTML([]byte(link.Target)))
+	_, _ = w.WriteString(`" title="`)
+	_, _ = w.Write(util.EscapeHTML([]byte(hint)))
+	_, _ = w.WriteString(`"`)
+	if link.Attributes() != nil {
+		html.RenderAttributes(w, link, html.GlobalAttributeFilter)
+	}
+	_, _ = w.WriteString(">")
+	return ast.WalkContinue, nil
+}
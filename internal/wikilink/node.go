@@ -0,0 +1,25 @@
+package wikilink
+
+import (
+	"github.com/yuin/goldmark/ast"
+)
+
+// BrokenLink represents a wiki link whose target is missing from the catalog.
+// Resolved wiki links become regular *ast.Link nodes instead.
+type BrokenLink struct {
+	ast.BaseInline
+	Target string
+	Lang   string
+}
+
+var KindBrokenLink = ast.NewNodeKind("BrokenLink")
+
+// Dump implements ast.Node.Dump
+func (n *BrokenLink) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Target": n.Target}, nil)
+}
+
+// Kind implements ast.Node.Kind
+func (n *BrokenLink) Kind() ast.NodeKind {
+	return KindBrokenLink
+}
@@ -0,0 +1,104 @@
+package wikilink
+
+import (
+	"bytes"
+	"log/slog"
+
+	"github.com/SayaAndy/saya-today-web/internal/mdcontext"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+var brokenContextKey = parser.NewContextKey()
+
+// Resolver looks up the title of a post by its codename in a language
+type Resolver interface {
+	Resolve(lang string, codename string) (title string, ok bool)
+}
+
+type WikiLinkParser struct {
+	resolver Resolver
+}
+
+func NewWikiLinkParser(resolver Resolver) parser.InlineParser {
+	return &WikiLinkParser{resolver: resolver}
+}
+
+func (p *WikiLinkParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (p *WikiLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+
+	end := bytes.Index(line, []byte("]]"))
+	if end < 0 {
+		return nil
+	}
+	inner := line[2:end]
+	if len(inner) == 0 || bytes.ContainsAny(inner, "[]") {
+		return nil
+	}
+
+	target, label, hasLabel := bytes.Cut(inner, []byte("|"))
+	target = bytes.TrimSpace(target)
+	if len(target) == 0 || (!hasLabel && bytes.EqualFold(target, []byte("toc"))) {
+		return nil
+	}
+
+	var labelSegment text.Segment
+	if hasLabel {
+		labelStart := 2 + len(inner) - len(label)
+		labelSegment = text.NewSegment(segment.Start+labelStart, segment.Start+end)
+		labelSegment = labelSegment.TrimLeftSpace(block.Source())
+		labelSegment = labelSegment.TrimRightSpace(block.Source())
+		if labelSegment.IsEmpty() {
+			hasLabel = false
+		}
+	}
+	block.Advance(end + 2)
+
+	lang := mdcontext.Lang(pc)
+	codename := string(target)
+
+	title, ok := codename, true
+	if lang != "" {
+		title, ok = p.resolver.Resolve(lang, codename)
+	}
+
+	var node ast.Node
+	if ok {
+		link := ast.NewLink()
+		link.Destination = []byte("./" + codename)
+		node = link
+	} else {
+		slog.Warn("broken wiki link",
+			slog.String("lang", lang),
+			slog.String("codename", mdcontext.Codename(pc)),
+			slog.String("target", codename),
+		)
+		broken, _ := pc.Get(brokenContextKey).([]string)
+		pc.Set(brokenContextKey, append(broken, codename))
+		node = &BrokenLink{Target: codename, Lang: lang}
+		title = codename
+	}
+
+	if hasLabel {
+		node.AppendChild(node, ast.NewTextSegment(labelSegment))
+	} else {
+		node.AppendChild(node, ast.NewString([]byte(title)))
+	}
+
+	return node
+}
+
+// Broken returns targets of the wiki links that could not be resolved while
+// parsing the document
+func Broken(pc parser.Context) []string {
+	broken, _ := pc.Get(brokenContextKey).([]string)
+	return broken
+}
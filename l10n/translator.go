@@ -1,6 +1,7 @@
 package l10n
 
 import (
+	"embed"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -13,6 +14,11 @@ import (
 
 var T *Translator
 
+// localeFiles are built into the binary, so packages using them load in tests too
+//
+//go:embed *.yaml
+var localeFiles embed.FS
+
 func init() {
 	var err error
 	if T, err = NewTranslator("ru", "en"); err != nil {
@@ -31,7 +37,7 @@ func NewTranslator(locales ...string) (*Translator, error) {
 	errs := make([]error, 0)
 
 	for _, locale := range locales {
-		contentBytes, err := os.ReadFile(fmt.Sprintf("l10n/%s.yaml", locale))
+		contentBytes, err := localeFiles.ReadFile(locale + ".yaml")
 		if err != nil {
 			errs = append(errs, fmt.Errorf("opening one of files: l10n/%s.yaml: %w", locale, err))
 			continue
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS mail_outbox_table_status_index;
+DROP TABLE IF EXISTS mail_outbox_table;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS mail_outbox_table (
+    message_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind VARCHAR(32) NOT NULL,
+    recipient VARCHAR(320) NOT NULL,
+    message BLOB NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(8) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    expires_at INTEGER,
+    sent_at INTEGER
+);
+
+CREATE INDEX mail_outbox_table_status_index
+ON mail_outbox_table(status, priority, next_attempt_at);
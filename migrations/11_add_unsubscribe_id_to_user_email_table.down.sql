@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS user_email_table_unsubscribe_id_uindex;
+ALTER TABLE user_email_table DROP COLUMN unsubscribe_id;
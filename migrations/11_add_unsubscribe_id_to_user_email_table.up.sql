@@ -0,0 +1,8 @@
+-- user_id changes with the salt and when the address moves to an account, so
+-- unsubscribe links are signed over an id of their own
+ALTER TABLE user_email_table ADD COLUMN unsubscribe_id VARCHAR(32) NOT NULL DEFAULT '';
+
+UPDATE user_email_table SET unsubscribe_id = lower(hex(randomblob(16)));
+
+CREATE UNIQUE INDEX user_email_table_unsubscribe_id_uindex
+ON user_email_table(unsubscribe_id);
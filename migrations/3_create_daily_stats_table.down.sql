@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS blog_daily_stats_day_index;
+DROP TABLE IF EXISTS blog_daily_stats;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS blog_daily_stats (
+    page_ref VARCHAR(32) NOT NULL,
+    lang VARCHAR(2) NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    new_viewers INTEGER NOT NULL DEFAULT 0,
+    likes INTEGER NOT NULL DEFAULT 0,
+    unlikes INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (page_ref, lang, day)
+) WITHOUT ROWID;
+
+CREATE INDEX blog_daily_stats_day_index
+ON blog_daily_stats(lang, day);
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS client_identity_table (
+    client_hash VARCHAR(32) NOT NULL PRIMARY KEY,
+    first_seen INTEGER NOT NULL
+) WITHOUT ROWID;
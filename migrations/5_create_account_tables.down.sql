@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS session_table_account_id_index;
+DROP TABLE IF EXISTS session_table;
+DROP INDEX IF EXISTS account_table_email_uindex;
+DROP TABLE IF EXISTS account_table;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS account_table (
+    account_id VARCHAR(32) NOT NULL PRIMARY KEY,
+    email VARCHAR(64) NOT NULL
+) WITHOUT ROWID;
+
+CREATE UNIQUE INDEX account_table_email_uindex
+ON account_table(email);
+
+CREATE TABLE IF NOT EXISTS session_table (
+    client_hash VARCHAR(32) NOT NULL PRIMARY KEY,
+    account_id VARCHAR(32) NOT NULL,
+    created_at INTEGER NOT NULL
+) WITHOUT ROWID;
+
+CREATE INDEX session_table_account_id_index
+ON session_table(account_id);
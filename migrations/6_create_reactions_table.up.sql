@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS blog_reactions (
+    page_ref VARCHAR(32) NOT NULL,
+    user_id VARCHAR(32) NOT NULL,
+    reaction VARCHAR(16) NOT NULL,
+    PRIMARY KEY (page_ref, user_id, reaction)
+) WITHOUT ROWID;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS comment_table_status_index;
+DROP INDEX IF EXISTS comment_table_page_index;
+DROP TABLE IF EXISTS comment_table;
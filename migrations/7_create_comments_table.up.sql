@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS comment_table (
+    comment_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    page_ref VARCHAR(32) NOT NULL,
+    lang VARCHAR(8) NOT NULL,
+    parent_id INTEGER REFERENCES comment_table(comment_id) ON DELETE CASCADE,
+    depth INTEGER NOT NULL DEFAULT 0,
+    email VARCHAR(64) NOT NULL,
+    author_name VARCHAR(64) NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX comment_table_page_index
+ON comment_table(page_ref, lang, status);
+
+CREATE INDEX comment_table_status_index
+ON comment_table(status);
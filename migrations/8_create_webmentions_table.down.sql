@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS webmention_table_page_index;
+DROP TABLE IF EXISTS webmention_table;
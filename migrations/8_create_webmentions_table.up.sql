@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS webmention_table (
+    source VARCHAR(2048) NOT NULL,
+    page_ref VARCHAR(32) NOT NULL,
+    lang VARCHAR(8) NOT NULL,
+    title VARCHAR(256) NOT NULL DEFAULT '',
+    verified_at INTEGER NOT NULL,
+    PRIMARY KEY (source, page_ref, lang)
+) WITHOUT ROWID;
+
+CREATE INDEX webmention_table_page_index
+ON webmention_table(page_ref, lang);
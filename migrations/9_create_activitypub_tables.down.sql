@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS activitypub_follower_table;
+DROP TABLE IF EXISTS activitypub_key_table;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS activitypub_key_table (
+    lang VARCHAR(8) PRIMARY KEY,
+    private_key TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS activitypub_follower_table (
+    lang VARCHAR(8) NOT NULL,
+    follower_id VARCHAR(2048) NOT NULL,
+    inbox VARCHAR(2048) NOT NULL,
+    shared_inbox VARCHAR(2048) NOT NULL DEFAULT '',
+    followed_at INTEGER NOT NULL,
+    PRIMARY KEY (lang, follower_id)
+) WITHOUT ROWID;